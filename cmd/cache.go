@@ -0,0 +1,95 @@
+//coverage:ignore
+/*
+Copyright © 2024 Agastya Dev Addepally (devagastya0@gmail.com)
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/thundersparkf/samwise/cmd/errorHandlers"
+	"github.com/thundersparkf/samwise/pkg/resolver"
+)
+
+// Cache directory the subcommands work in, failing rather than falling back to the working directory when it is unknown
+func getCommandCacheDir() (string, error) {
+	cacheDir := getCacheDir()
+	if cacheDir == "" {
+		return "", errors.New(errorHandlers.CacheDirError)
+	}
+	return cacheDir, nil
+}
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the local cache of remote tag listings",
+	Long: `
+
+	checkForUpdates caches the tags of every module source it looks up so repeated runs don't contact every repository again.
+	The cache lives under the user cache directory(samwise) unless cache_dir is set in the config.
+
+Even the smallest person can change the course of the future, the cache only saves them the walk.`,
+}
+
+// cacheListCmd represents the cache list command
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the cached module sources, their tag counts and when they were fetched",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		cacheDir, err := getCommandCacheDir()
+		if err != nil {
+			return err
+		}
+		entries, err := resolver.ListCache(cacheDir)
+		if err != nil {
+			return errors.New("unable to list cache entries " + err.Error())
+		}
+		writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		_, err = fmt.Fprintln(writer, "URL\tTAGS\tFETCHED_AT")
+		for _, entry := range entries {
+			if err != nil {
+				break
+			}
+			_, err = fmt.Fprintln(writer, entry.Url+"\t"+strconv.Itoa(len(entry.Tags))+"\t"+entry.FetchedAt.Format(time.RFC3339))
+		}
+		if err != nil {
+			return err
+		}
+		return writer.Flush()
+	},
+}
+
+// cacheClearCmd represents the cache clear command
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove every cached tag listing",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		cacheDir, err := getCommandCacheDir()
+		if err != nil {
+			return err
+		}
+		removed, err := resolver.ClearCache(cacheDir)
+		if err != nil {
+			return errors.New("unable to clear cache " + err.Error())
+		}
+		log.Debug().Msgf("cache :: clear :: removed :: %d", removed)
+		_, err = fmt.Fprintln(cmd.OutOrStdout(), "Removed "+strconv.Itoa(removed)+" cache entries")
+		return err
+	},
+}
+
+func init() {
+	cobra.OnInitialize(initConfig)
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheListCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thundersparkf/samwise/cmd/errorHandlers"
+)
+
+func TestCacheClearWithoutCacheDir(t *testing.T) {
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+	dir := t.TempDir()
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { _ = os.Chdir(workingDir) })
+	// No cache_dir and no user cache directory to fall back to
+	t.Cleanup(func() { viper.Set("cache_dir", nil) })
+	viper.Set("cache_dir", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+	t.Setenv("HOME", "")
+	for _, file := range []string{"package.json", "module_report.json"} {
+		require.NoError(t, os.WriteFile(file, []byte("{}"), os.ModePerm))
+	}
+
+	assert.EqualError(t, cacheClearCmd.RunE(cacheClearCmd, nil), errorHandlers.CacheDirError)
+	assert.EqualError(t, cacheListCmd.RunE(cacheListCmd, nil), errorHandlers.CacheDirError)
+	assert.FileExists(t, dir+"/package.json")
+	assert.FileExists(t, dir+"/module_report.json")
+}
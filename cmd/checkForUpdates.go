@@ -6,27 +6,30 @@ package cmd
 
 import (
 	"errors"
-	"io/fs"
-	"os"
-	"path/filepath"
-	"slices"
+	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
-	"github.com/schollz/progressbar/v3"
 	"github.com/spf13/cobra"
+	"github.com/thundersparkf/samwise/cmd/errorHandlers"
+	"github.com/thundersparkf/samwise/pkg/resolver"
+	"github.com/thundersparkf/samwise/pkg/scanner"
 )
 
-var modulesListTotal []map[string]string
-var failureListTotal []map[string]string
 var Path string
 var OutputFormat string
 var OutputFilename string
+var OutputDir string
+var Stdout bool
 var LatestVersion bool
 var MajorUpgrade bool
+var FailOn []string
 var Depth int
+var Concurrency int
 var DirectoriesToIgnore []string
+var RequirePinned bool
 
 // checkForUpdatesCmd represents the checkForUpdates command
 var checkForUpdatesCmd = &cobra.Command{
@@ -36,112 +39,93 @@ var checkForUpdatesCmd = &cobra.Command{
 
 	Searches (sub)directories for module sources and versions to create a report listing versions available for updates.
 
-CSV format : repo_link | current_version | updates_available
+	Git sources are checked against the tags of the repository and Terraform Registry sources against the registry's
+	module versions. Unpinned module blocks and failed lookups are listed too, the latter in a failure report.
+	See the README for the report formats, the exit codes of --fail-on and --require-pinned and the failure codes.
 
-JSON format: [{
-                "repo_link": <repo_link>,
-                "current_version": <current version used in the code>,
-                "updates_available"
-             }]
+CSV format : repo_link | current_version | file_name | updates_available | latest_matching_version | constraint_excludes_latest |
+             module_name | submodule | line | column | end_line | end_column | pinning
+
+JSON format: schemas/report.v1.schema.json
 
 An update is never late, nor is it early, it arrives precisely when it means to.
 	`,
 
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		log.Debug().Msgf("creating a report: verbose %s, Latest Version: %t", v, LatestVersion)
 		log.Debug().Msg("output format: " + OutputFormat)
 		log.Debug().Msgf("Params: Depth=%s, rootDir=%s, Path=%s", strconv.Itoa(Depth), Path, strings.Join(DirectoriesToIgnore, " "))
 		rootDir := fixTrailingSlashForPath(Path)
-		var failureList []map[string]string
-		err := filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
-			Check(err, "checkForUpdates :: command :: ", path)
-			isAllowedDir, dirError := directorySearch(rootDir, path, d)
-			if errors.Is(dirError, fs.SkipDir) {
-				return dirError
-			}
-			if isAllowedDir {
-				modules, failureList := checkForModuleSourceUpdates(path, LatestVersion)
-				log.Debug().Msgf("checkForUpdates :: command :: modules :: %v", modules)
-				modulesListTotal = append(modulesListTotal, modules...)
-				failureListTotal = append(failureListTotal, failureList...)
-			}
-			return nil
-		})
-		Check(err, "checkForUpdates :: command :: unable to walk the directories")
+		failOn, err := checkFailOn(FailOn)
+		if err != nil {
+			return errorHandlers.UsageError(err)
+		}
 		OutputFormat, err = checkOutputFormat(OutputFormat)
-		Check(err, "checkForUpdates :: command :: output format error", OutputFormat)
+		if err != nil {
+			return errorHandlers.UsageError(err)
+		}
+		MarkdownGroupBy, err = checkMarkdownGroupBy(MarkdownGroupBy)
+		if err != nil {
+			return errorHandlers.UsageError(err)
+		}
+		// Flags are valid, errors from here on are not about usage
+		cmd.SilenceUsage = true
+		if Stdout {
+			OutputFilename = stdoutFilename
+		}
 		OutputFilename = checkOutputFilename(OutputFilename)
-		log.Debug().Msgf("checkForUpdates :: command :: modulesListTotal :: %v", modulesListTotal)
-		generateReport(modulesListTotal, OutputFilename, OutputFormat, rootDir)
-		createJSONReportFile(failureList, rootDir, "failure_report")
-
-	},
-}
-
-func directorySearch(rootDir string, path string, d fs.DirEntry) (bool, error) {
-	depthCountInCurrentPath := strings.Count(rootDir, string(os.PathSeparator))
-	if d.IsDir() {
-		if !slices.Contains(DirectoriesToIgnore, d.Name()) {
-			log.Info().Msg("checkForUpdates :: command :: in directory " + path)
-			if Depth != -1 {
-				if strings.Count(path, string(os.PathSeparator)) > depthCountInCurrentPath+Depth {
-					log.Info().Msg("...which is skipped")
-					return true, fs.SkipDir
-				}
-			}
-			return true, nil
+		outputDir, err := getOutputDir(OutputDir, rootDir)
+		if err != nil {
+			return err
 		}
-		return true, fs.SkipDir
-	}
-	return false, nil
-}
 
-func checkForModuleSourceUpdates(path string, latestVersion bool) ([]map[string]string, []map[string]string) {
-	var modules []map[string]string
-	var failureList []map[string]string
-	var listWritten []string
-	var bar *progressbar.ProgressBar
-	path = fixTrailingSlashForPath(path)
-	modules = processRepoLinksAndTags(path)
-	log.Debug().Msg("checkForUpdates :: checkForModuleSourceUpdates :: path: " + path)
-
-	log.Info().Msg("Scanning directory " + path + " ...")
-	if len(modules) > 0 {
-		bar = progressbar.Default(int64(len(modules)))
-	}
-	for _, module := range modules {
-		err := bar.Add(1)
-		Check(err, "progressbar error")
-		moduleUsed := module["repo"] + ":" + module["current_version"]
-		if !slices.Contains(listWritten, moduleUsed) {
-			_, tagsList, err := processGitRepo(module["repo"], module["current_version"])
-			if err != nil {
-				failureList = append(failureList, map[string]string{
-					"repo":              module["repo"],
-					"current_version":   module["current_version"],
-					"updates_available": tagsList,
-					"error":             err.Error(),
-				})
-			}
-			if len(tagsList) > 0 {
-				latestVersionString := getGreatestSemverFromList(tagsList)
-				if latestVersion {
-					module["latest_version"] = latestVersionString
-				} else {
-					module["updates_available"] = tagsList
-				}
-				isModuleUpgradePriorityHigh := isMajorReleaseUpgrade(module["current_version"], latestVersionString)
-				if MajorUpgrade && isModuleUpgradePriorityHigh {
-					module["repo"] = module["repo"] + "[MAJOR UPGRADE AVAILABLE]"
-				}
-				listWritten = append(listWritten, moduleUsed)
-			}
-			log.Debug().Msgf("checkForUpdates :: checkForModuleSourceUpdates :: path :: repo :: %s :: current :: %s :: updates_available :: %s :: latest_update :: %s", module["repo"], module["current_version"], module["updates_available"], module["latest_version"])
+		moduleResolver, err := newModuleResolver()
+		if err != nil {
+			return err
+		}
 
+		var scanFailures []UpdateResult
+		modules, err := scanner.New(getScannerOptions(rootDir, func(path string, err error) error {
+			log.Warn().Msgf("checkForUpdates :: command :: skipping %s :: %s", path, err.Error())
+			scanFailures = append(scanFailures, newScanFailure(path, err))
+			return nil
+		})).Scan()
+		if err != nil {
+			return errors.New(errorHandlers.ScanningErrorPrefix + err.Error())
 		}
-	}
+		updateResults, failureList, err := moduleResolver.Resolve(cmd.Context(), modules)
+		if err != nil {
+			return err
+		}
+		failureList = append(scanFailures, failureList...)
+		log.Debug().Msgf("checkForUpdates :: command :: updateResults :: %v", updateResults)
+		err = generateReport(updateResults, failureList, OutputFilename, OutputFormat, outputDir)
+		if err != nil {
+			return err
+		}
+		failureReportFilename := failureReportFilename
+		if OutputFilename == stdoutFilename {
+			// stdout only carries the modules report so it can be piped
+			failureReportFilename = stderrFilename
+		}
+		err = generateFailureReport(failureList, failureReportFilename, OutputFormat, outputDir)
+		if err != nil {
+			return err
+		}
+		if unpinned := getUnpinnedModules(updateResults); len(unpinned) > 0 {
+			log.Warn().Msgf("checkForUpdates :: command :: %d module(s) not pinned to a version", len(unpinned))
+		}
+		if exitCode := getExitCode(failOn, RequirePinned, updateResults, failureList); exitCode != 0 {
+			log.Warn().Msgf("checkForUpdates :: command :: fail-on %s(require-pinned %t) met, exiting with %d", strings.Join(failOn, ","), RequirePinned, exitCode)
+			return &errorHandlers.ExitError{Code: exitCode}
+		}
+		return nil
+	},
+}
 
-	return modules, failureList
+// Failure report entry of a directory or file that could not be scanned
+func newScanFailure(path string, err error) UpdateResult {
+	return resolver.NewFailedResult(ModuleUsage{FileName: path}, fmt.Errorf(errorHandlers.ScanningErrorPrefix+"%w", err))
 }
 
 // Fixed return of params depth, rootDir, directoriesToIgnore, output, outputFilename
@@ -161,17 +145,23 @@ func checkForModuleSourceUpdates(path string, latestVersion bool) ([]map[string]
 
 func init() {
 	cobra.OnInitialize(initConfig)
-	//checkForUpdatesCmd.Flags().Bool("ci", false, "Set this flag for usage in CI systems. Does not generate a report. Prints JSON to Stdout and returns exit code 1 if modules are outdated.")
-	//checkForUpdatesCmd.Flags().Bool("allow-failure", true, "Set this flag for usage in CI systems. If true, does NOT exit code 1 when modules are outdated.")
 	rootCmd.AddCommand(checkForUpdatesCmd)
 
 	checkForUpdatesCmd.PersistentFlags().IntVarP(&Depth, "depth", "d", 0, "Folder depth to search for modules in. Give -1 for a full directory extraction. Default 0, which only reads the projectory.")
+	checkForUpdatesCmd.PersistentFlags().DurationVar(&CacheTTL, "cache-ttl", time.Hour, "How long cached tag listings of module sources are used before they are fetched again.")
+	checkForUpdatesCmd.PersistentFlags().BoolVar(&NoCache, "no-cache", false, "Always fetch the tags of module sources instead of using the local cache.")
+	checkForUpdatesCmd.PersistentFlags().IntVar(&Concurrency, "concurrency", 8, "Number of module sources looked up in parallel.")
 	checkForUpdatesCmd.PersistentFlags().StringVar(&Path, "path", "p", "The path for directory containing terraform code to extract modules from.")
 	checkForUpdatesCmd.PersistentFlags().String("git-repo", "g", "Git Repository to check module dependencies on.")
 	checkForUpdatesCmd.PersistentFlags().StringSliceVarP(&DirectoriesToIgnore, "ignore", "i", []string{".git", ".idea"}, "Directories to ignore when searching for the One Ring(modules and their sources.")
-	checkForUpdatesCmd.PersistentFlags().StringVarP(&OutputFormat, "output", "o", "csv", "Output format. Supports \"csv\" and \"json\". Default value is csv.")
-	checkForUpdatesCmd.PersistentFlags().StringVarP(&OutputFilename, "output-filename", "f", "module_report", "Output file name.")
-	checkForUpdatesCmd.Flags().BoolVar(&LatestVersion, "latest-version", false, "Include only latest version in report.")
+	checkForUpdatesCmd.PersistentFlags().StringVarP(&OutputFormat, "output", "o", "csv", "Output format. Supports \"csv\", \"json\", \"markdown\", \"sarif\", \"junit\" and \"html\". Default value is csv.")
+	checkForUpdatesCmd.PersistentFlags().StringVar(&MarkdownGroupBy, "markdown-group-by", "file", "Group the markdown report by \"file\" or \"repo\".")
+	checkForUpdatesCmd.PersistentFlags().StringVarP(&OutputFilename, "output-filename", "f", "module_report", "Output file name. Give - to print the report to stdout(the failure report goes to stderr).")
+	checkForUpdatesCmd.PersistentFlags().BoolVar(&Stdout, "stdout", false, "Print the report to stdout, same as --output-filename -.")
+	checkForUpdatesCmd.PersistentFlags().StringVar(&OutputDir, "output-dir", "", "Directory to write the reports to. Defaults to the scanned directory.")
+	checkForUpdatesCmd.Flags().BoolVar(&LatestVersion, "latest-version", false, "Include only the latest version instead of every update in the csv report.")
+	checkForUpdatesCmd.Flags().StringSliceVar(&FailOn, "fail-on", []string{}, "Exit with a non-zero code after writing the report when modules are outdated(any, minor, major) or lookups failed(failure), 10 for failures and 11, 12 or 13 for the most severe update. Comma separated.")
+	checkForUpdatesCmd.Flags().BoolVar(&RequirePinned, "require-pinned", false, "Exit with a non-zero code after writing the report when module blocks are pinned to a commit, a branch or nothing rather than a version, 14 unless lookups failed.")
 	checkForUpdatesCmd.Flags().BoolVar(&MajorUpgrade, "major", false, "Highlight modules that have a major version update in report.")
 
 	err := checkForUpdatesCmd.MarkPersistentFlagRequired("path")
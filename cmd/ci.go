@@ -9,12 +9,18 @@ import (
 	"errors"
 	"io/fs"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/go-git/go-git/v5"
+	"github.com/hashicorp/terraform-exec/tfexec"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
+	"github.com/thundersparkf/samwise/cmd/errorHandlers"
+	"github.com/thundersparkf/samwise/pkg/forge"
+	"github.com/thundersparkf/samwise/pkg/resolver"
+	"github.com/thundersparkf/samwise/pkg/scanner"
 )
 
 var filesUpdatedTotal []string
@@ -28,56 +34,256 @@ var ciCmd = &cobra.Command{
 	Includes features for better CI integrations such as failure when updates available
 	for pipelines, allowing users to automatically create PRs when updates are present(custom thresholds) and so on.
 
+	Updates the refs of git module sources to their latest tag and commits the files updated to an upgrade branch,
+	one per group with --group-by. --push and --pull-request push the branches and open a pull request for each,
+	--dry-run prints the diff without changing anything. See the README for the branch template fields, the forges
+	and the config used.
+
 Not all those who don't update dependencies are lost.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		log.Debug().Msgf("Ci stuff... in %s with args count %d", Path, len(args))
 		//_, _, directoriesToIgnore, _, _ := getParamsForCheckForUpdatesCMD(cmd.Flags())
 		log.Debug().Msg("output format: " + OutputFormat)
 		log.Debug().Msgf("Params: Depth=%s, rootDir=%s, Path=%s", strconv.Itoa(Depth), Path, strings.Join(DirectoriesToIgnore, " "))
+		var err error
+		GroupBy, err = checkCiGroupBy(GroupBy)
+		if err != nil {
+			return errorHandlers.UsageError(err)
+		}
+		now := time.Now()
+		exampleGroup := ""
+		if GroupBy != ciGroupByAll {
+			exampleGroup = GroupBy
+		}
+		if _, err = getUpgradeBranchName(BranchTemplate, now, exampleGroup); err != nil {
+			return errorHandlers.UsageError(err)
+		}
+		if PlanFile != "" && !DryRun {
+			return errorHandlers.UsageError(errors.New("--plan-file is only written with --dry-run"))
+		}
+		var pullRequestForge forge.Forge
+		if OpenPullRequest {
+			if !Push {
+				return errorHandlers.UsageError(errors.New("--pull-request needs the upgrade branch to be pushed with --push"))
+			}
+			if pullRequestForge, err = newForge(); err != nil {
+				return errorHandlers.UsageError(err)
+			}
+		}
+		cmd.SilenceUsage = true
 		rootDir := fixTrailingSlashForPath(Path)
-		tf := setupTerraform(rootDir, "1.9.8")
-		if tf == nil {
-			return
-		}
-		var modules []map[string]string
-		var failureList []map[string]string
-		err := filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
-			Check(err, "ci :: command :: ", path)
-
-			Check(err, "checkForUpdates :: command :: ", path)
-			isAllowedDir, dirError := directorySearch(rootDir, path, d)
-			if errors.Is(dirError, fs.SkipDir) {
-				return dirError
+		// Upgrade branches are created from the branch checked out before the run, which is where groups start from
+		// and pull requests are merged into by default. A dry run doesn't need a repository.
+		var startBranch string
+		if !DryRun && (GroupBy != ciGroupByAll || (OpenPullRequest && BaseBranch == "")) {
+			if startBranch, err = getCurrentBranch(rootDir); err != nil {
+				return err
 			}
-			if isAllowedDir {
-				err := tf.FormatWrite(context.TODO())
-				Check(err, "tf fmt failed")
-				filesUpdated := createModuleVersionUpdates(path)
-				filesUpdatedTotal = append(filesUpdatedTotal, filesUpdated...)
-				filesUpdatedTotal = removeDuplicateStr(filesUpdatedTotal)
-				modulesListTotal = append(modulesListTotal, modules...)
-				failureListTotal = append(failureListTotal, failureList...)
+		}
+		upgrade := upgradeOptions{rootDir: rootDir, startBranch: startBranch, base: BaseBranch, repository: ForgeRepository, forge: pullRequestForge}
+		if upgrade.base == "" {
+			upgrade.base = startBranch
+		}
+		if !DryRun && OpenPullRequest && upgrade.repository == "" {
+			if upgrade.repository, err = getForgeRepository(rootDir, Remote); err != nil {
+				return err
 			}
-
+		}
+		// A dry run formats the files too so its diff is what a real run writes
+		tf, err := setupTerraform(rootDir, "1.9.8")
+		if err != nil {
+			return err
+		}
+		upgrade.format = getTerraformFormat(tf)
+		upgrade.directories, err = scanner.New(getScannerOptions(rootDir, func(path string, err error) error {
+			log.Warn().Msgf("ci :: command :: skipping %s :: %s", path, err.Error())
 			return nil
-		})
-		Check(err, "ci :: command :: unable to walk the directories")
+		})).Directories()
+		if err != nil {
+			return errors.New(errorHandlers.ScanningErrorPrefix + err.Error())
+		}
+		if upgrade.resolver, err = newModuleResolver(); err != nil {
+			return err
+		}
+		if DryRun {
+			return dryRunModuleUpdates(cmd.Context(), upgrade, now, cmd.OutOrStdout())
+		}
+		// With --group-by the bumps are planned without making any, then made and committed a group at a time
+		groups := []bumpGroup{{}}
+		if GroupBy != ciGroupByAll {
+			plannedUpdates, err := updateDirectories(cmd.Context(), upgrade, nil, false)
+			if err != nil {
+				return err
+			}
+			plannedBumps, _ := getModuleBumps(plannedUpdates)
+			groups = groupModuleBumps(plannedBumps, GroupBy, rootDir)
+			log.Debug().Msgf("ci :: command :: groups :: %d :: bumps :: %d", len(groups), len(plannedBumps))
+			if len(groups) == 0 {
+				log.Info().Msg("No module updates to commit")
+			}
+		}
+		for _, group := range groups {
+			slug := ""
+			if group.Key != "" {
+				slug = getBranchSlug(group.Key)
+			}
+			branch, err := getUpgradeBranchName(BranchTemplate, now, slug)
+			if err != nil {
+				return err
+			}
+			if err = upgradeModuleGroup(cmd.Context(), upgrade, group, branch); err != nil {
+				return err
+			}
+		}
 		log.Debug().Msgf("ci :: command :: filesUpdatedTotal :: %s", strings.Join(filesUpdatedTotal, " "))
-		//writeCommit(rootDir)
-
+		return nil
 	},
 }
 
-func createModuleVersionUpdates(path string) []string {
+// What upgrade branches are made from and how they are committed, pushed and opened pull requests for
+type upgradeOptions struct {
+	rootDir     string
+	directories []string
+	// Formats the files the bumps are made in before they are written, when it isn't nil
+	format   func(ctx context.Context, content []byte) ([]byte, error)
+	resolver *resolver.Resolver
+	// Branch checked out before the run, empty with --group-by=all and no pull request
+	startBranch string
+	// Branch and repository pull requests are opened for on forge, when it isn't nil
+	base       string
+	repository string
+	forge      forge.Forge
+}
+
+// Works out the module bumps of every directory, formatting and writing the files with bumps when write is set
+func updateDirectories(ctx context.Context, upgrade upgradeOptions, include func(moduleBump) bool, write bool) ([]tfFileUpdate, error) {
+	var updates []tfFileUpdate
+	for _, path := range upgrade.directories {
+		directoryUpdates, err := createModuleVersionUpdates(ctx, upgrade.resolver, path, include)
+		if err != nil {
+			return nil, err
+		}
+		for i := range directoryUpdates {
+			if !write {
+				break
+			}
+			if err = formatTfFileUpdate(ctx, upgrade, &directoryUpdates[i]); err != nil {
+				return nil, err
+			}
+			if err = writeTfFile(directoryUpdates[i].FileName, directoryUpdates[i].After); err != nil {
+				return nil, err
+			}
+		}
+		updates = append(updates, directoryUpdates...)
+	}
+	return updates, nil
+}
+
+// Formats the contents the update writes, when the upgrade has a formatter
+func formatTfFileUpdate(ctx context.Context, upgrade upgradeOptions, update *tfFileUpdate) error {
+	if upgrade.format == nil {
+		return nil
+	}
+	formatted, err := upgrade.format(ctx, update.After)
+	if err != nil {
+		return errors.New("terraform fmt failed " + update.FileName + " " + err.Error())
+	}
+	update.After = formatted
+	return nil
+}
+
+// Formats content with terraform fmt through stdin, so only the files given to it are formatted
+func getTerraformFormat(tf *tfexec.Terraform) func(ctx context.Context, content []byte) ([]byte, error) {
+	return func(ctx context.Context, content []byte) ([]byte, error) {
+		formatted, err := tf.FormatString(ctx, string(content))
+		return []byte(formatted), err
+	}
+}
+
+// Bumps of the file updates and the files they are in
+func getModuleBumps(updates []tfFileUpdate) ([]moduleBump, []string) {
+	var moduleBumps []moduleBump
+	var files []string
+	for _, update := range updates {
+		moduleBumps = append(moduleBumps, update.Bumps...)
+		files = append(files, update.FileName)
+	}
+	return moduleBumps, removeDuplicateStr(files)
+}
+
+// Makes and commits the module bumps of the group to the branch, pushing it and opening a pull request when asked to
+func upgradeModuleGroup(ctx context.Context, upgrade upgradeOptions, group bumpGroup, branch string) error {
+	var include func(moduleBump) bool
+	originals := make(map[string][]byte)
+	if group.Key != "" {
+		include = func(bump moduleBump) bool {
+			key, _ := getBumpGroup(bump, GroupBy, upgrade.rootDir)
+			return key == group.Key
+		}
+		for _, bump := range group.Bumps {
+			content, err := os.ReadFile(bump.FileName)
+			if err != nil {
+				return errors.New(errorHandlers.CommitErrorPrefix + err.Error())
+			}
+			originals[bump.FileName] = content
+		}
+	}
+	updates, err := updateDirectories(ctx, upgrade, include, true)
+	if err != nil {
+		return err
+	}
+	moduleBumps, files := getModuleBumps(updates)
+	filesUpdatedTotal = removeDuplicateStr(append(filesUpdatedTotal, files...))
+	if len(moduleBumps) == 0 {
+		log.Info().Msg("No module updates to commit")
+		return nil
+	}
+	message := getCommitMessage(moduleBumps, group.Description)
+	commit, err := commitModuleUpdates(upgrade.rootDir, branch, files, message)
+	if err != nil {
+		return err
+	}
+	log.Info().Msgf("Committed %d module update(s) to %s as %s", len(moduleBumps), branch, commit.String())
+	if group.Key != "" {
+		if err = checkoutBaseBranch(upgrade.rootDir, upgrade.startBranch, originals); err != nil {
+			return err
+		}
+	}
+	if !Push {
+		return nil
+	}
+	if err = pushUpgradeBranch(ctx, upgrade.resolver, upgrade.rootDir, Remote, branch); err != nil || upgrade.forge == nil {
+		return err
+	}
+	return openUpgradePullRequest(ctx, upgrade.forge, upgrade.repository, branch, upgrade.base, message, moduleBumps)
+}
+
+// Returns the files directly in path with module bumps include returns true for, skipping unreadable files
+func createModuleVersionUpdates(ctx context.Context, moduleResolver *resolver.Resolver, path string, include func(moduleBump) bool) ([]tfFileUpdate, error) {
 	files, err := os.ReadDir(fixTrailingSlashForPath(path))
-	var filesUpdated []string
-	Check(err, "util :: updateTfFiles :: unable to read dir")
+	var filesUpdated []tfFileUpdate
+	if err != nil {
+		log.Warn().Msgf("ci :: createModuleVersionUpdates :: skipping %s :: %s", path, err.Error())
+		return filesUpdated, nil
+	}
 	for _, file := range files {
-		filesEdited := updateTfFiles(path, file.Name())
-		filesUpdated = append(filesUpdated, filesEdited...)
+		if file.IsDir() {
+			continue
+		}
+		fileUpdate, err := getTfFileUpdate(ctx, moduleResolver, path, file.Name(), include)
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+			log.Warn().Msgf("ci :: createModuleVersionUpdates :: skipping %s :: %s", file.Name(), err.Error())
+			continue
+		}
+		if err != nil {
+			return filesUpdated, err
+		}
+		if len(fileUpdate.Bumps) > 0 {
+			filesUpdated = append(filesUpdated, fileUpdate)
+		}
 	}
-	log.Debug().Msgf("ci :: command :: files :: %s", strings.Join(filesUpdated, " "))
-	return filesUpdated
+	log.Debug().Msgf("ci :: command :: files with module updates :: %d", len(filesUpdated))
+	return filesUpdated, nil
 }
 
 func init() {
@@ -92,4 +298,15 @@ func init() {
 
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
+	ciCmd.Flags().BoolVar(&DryRun, "dry-run", false, "Print a unified diff of the formatted module updates instead of writing, committing and pushing them. The tag cache is read but not written.")
+	ciCmd.Flags().StringVar(&PlanFile, "plan-file", "", "Write the module updates of a dry run as a JSON plan to the file, - for stdout after the diff.")
+	ciCmd.Flags().StringVar(&GroupBy, "group-by", ciGroupByAll, "Commit the module updates to one branch(\"all\") or a branch per upstream \"repo\", terraform \"directory\" or \"severity\"(major against minor and patch).")
+	ciCmd.Flags().StringVar(&BranchTemplate, "branch-template", defaultBranchTemplate, "Name of the upgrade branch the updates are committed to, a text/template with {{.Date}} and {{.Time}}.")
+	ciCmd.Flags().BoolVar(&Push, "push", false, "Push the upgrade branch to --remote after committing.")
+	ciCmd.Flags().StringVar(&Remote, "remote", git.DefaultRemoteName, "Name of the remote the upgrade branch is pushed to.")
+	ciCmd.Flags().BoolVar(&OpenPullRequest, "pull-request", false, "Open a pull request from the pushed upgrade branch, or update the open one. Needs --push.")
+	ciCmd.Flags().StringVar(&ForgeName, "forge", forge.GitHubForge, "Forge the pull request is opened on. Supports "+strings.Join(forge.Forges, ", ")+".")
+	ciCmd.Flags().StringVar(&ForgeURL, "forge-url", "", "Base url of the forge API, for self-hosted forges. The API of the public forge by default.")
+	ciCmd.Flags().StringVar(&BaseBranch, "base-branch", "", "Branch the pull request is merged into. The branch checked out before the run by default.")
+	ciCmd.Flags().StringVar(&ForgeRepository, "forge-repository", "", "Repository the pull request is opened in, e.g. org/infra. Read from the url of --remote by default.")
 }
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/rs/zerolog/log"
+	"github.com/thundersparkf/samwise/cmd/errorHandlers"
+)
+
+var DryRun bool
+var PlanFile string
+
+// Module bump ci would make, as written to --plan-file
+type planChange struct {
+	File         string `json:"file"`
+	Module       string `json:"module"`
+	Source       string `json:"source"`
+	From         string `json:"from"`
+	To           string `json:"to"`
+	MajorUpgrade bool   `json:"major_upgrade"`
+	// Upgrade branch the bump would be committed to
+	Branch string `json:"branch"`
+}
+
+type upgradePlan struct {
+	Changes []planChange `json:"changes"`
+}
+
+// Path of the file relative to the root directory, as it is shown in diffs and plans
+func getDisplayPath(rootDir string, file string) string {
+	relativePath, err := filepath.Rel(rootDir, file)
+	if err != nil {
+		return file
+	}
+	return filepath.ToSlash(relativePath)
+}
+
+// Lines of the content with their line endings, unlike difflib.SplitLines without an empty line after the last one
+func getDiffLines(content []byte) []string {
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Unified diff of the contents of the file before and after the module bumps, a/ and b/ prefixed like git's
+func getUnifiedDiff(update tfFileUpdate, name string) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        getDiffLines(update.Before),
+		B:        getDiffLines(update.After),
+		FromFile: "a/" + name,
+		ToFile:   "b/" + name,
+		Context:  3,
+	})
+}
+
+// Every module bump of the updates with the upgrade branch --group-by and --branch-template would commit it to
+func getUpgradePlan(updates []tfFileUpdate, rootDir string, now time.Time) (upgradePlan, error) {
+	plan := upgradePlan{Changes: make([]planChange, 0)}
+	for _, update := range updates {
+		for _, bump := range update.Bumps {
+			slug := ""
+			if key, _ := getBumpGroup(bump, GroupBy, rootDir); GroupBy != ciGroupByAll {
+				slug = getBranchSlug(key)
+			}
+			branch, err := getUpgradeBranchName(BranchTemplate, now, slug)
+			if err != nil {
+				return plan, err
+			}
+			plan.Changes = append(plan.Changes, planChange{
+				File:         getDisplayPath(rootDir, bump.FileName),
+				Module:       bump.Name,
+				Source:       bump.Repo,
+				From:         bump.From,
+				To:           bump.To,
+				MajorUpgrade: bump.IsMajorUpgrade,
+				Branch:       branch,
+			})
+		}
+	}
+	return plan, nil
+}
+
+// Prints the diff of every file the module bumps would change to out and writes the plan of --plan-file
+func dryRunModuleUpdates(ctx context.Context, upgrade upgradeOptions, now time.Time, out io.Writer) error {
+	updates, err := updateDirectories(ctx, upgrade, nil, false)
+	if err != nil {
+		return err
+	}
+	for i, update := range updates {
+		if err = formatTfFileUpdate(ctx, upgrade, &updates[i]); err != nil {
+			return err
+		}
+		diff, err := getUnifiedDiff(updates[i], getDisplayPath(upgrade.rootDir, update.FileName))
+		if err != nil {
+			return errors.New(errorHandlers.DryRunErrorPrefix + err.Error())
+		}
+		if _, err = io.WriteString(out, diff); err != nil {
+			return errors.New(errorHandlers.DryRunErrorPrefix + err.Error())
+		}
+	}
+	moduleBumps, files := getModuleBumps(updates)
+	log.Info().Msgf("Dry run, %d module update(s) in %d file(s) not written", len(moduleBumps), len(files))
+	if PlanFile == "" {
+		return nil
+	}
+	plan, err := getUpgradePlan(updates, upgrade.rootDir, now)
+	if err != nil {
+		return err
+	}
+	planJson, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return errors.New(errorHandlers.DryRunErrorPrefix + err.Error())
+	}
+	planJson = append(planJson, '\n')
+	if PlanFile == "-" {
+		_, err = out.Write(planJson)
+	} else {
+		err = os.WriteFile(PlanFile, planJson, 0644)
+	}
+	if err != nil {
+		return errors.New(errorHandlers.DryRunErrorPrefix + "unable to write plan " + err.Error())
+	}
+	return nil
+}
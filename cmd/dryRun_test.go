@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thundersparkf/samwise/pkg/resolver"
+	"github.com/thundersparkf/samwise/pkg/scanner"
+)
+
+// Functions to help testing
+
+const testModulesTf = `module "vpc" {
+  source = "git::https://github.com/org/vpc.git?ref=v1.0.0"
+}
+
+module "eks" {
+  source = "git::https://github.com/org/eks.git?ref=v1.0.0"
+}
+`
+
+// Resolver answering tag listings of the git sources from its cache rather than the network
+func newCachedModuleResolver(t *testing.T, tags map[string][]string) *resolver.Resolver {
+	cacheDir := t.TempDir()
+	for repo, repoTags := range tags {
+		require.NoError(t, resolver.WriteCache(cacheDir, scanner.GitSourceType, repo, repoTags))
+	}
+	return resolver.New(resolver.Options{CacheDir: cacheDir, CacheTTL: time.Hour})
+}
+
+// Upgrade of the root directory with a vpc module getting a major upgrade and an eks module getting a minor one
+func newTestUpgrade(t *testing.T, rootDir string) upgradeOptions {
+	require.NoError(t, os.WriteFile(rootDir+"/main.tf", []byte(testModulesTf), os.ModePerm))
+	return upgradeOptions{
+		rootDir:     rootDir,
+		directories: []string{rootDir},
+		resolver: newCachedModuleResolver(t, map[string][]string{
+			"git::https://github.com/org/vpc.git": {"v1.0.0", "v2.0.0"},
+			"git::https://github.com/org/eks.git": {"v1.0.0", "v1.1.0"},
+		}),
+	}
+}
+
+func TestGetUnifiedDiff(t *testing.T) {
+	diff, err := getUnifiedDiff(tfFileUpdate{Before: []byte("a\nb\nc\n"), After: []byte("a\nB\nc\n")}, "infra/main.tf")
+	require.NoError(t, err)
+	assert.Equal(t, "--- a/infra/main.tf\n+++ b/infra/main.tf\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n", diff)
+}
+
+func TestDryRunModuleUpdates(t *testing.T) {
+	planFile, groupBy, branchTemplate := PlanFile, GroupBy, BranchTemplate
+	t.Cleanup(func() { PlanFile, GroupBy, BranchTemplate = planFile, groupBy, branchTemplate })
+	rootDir := t.TempDir()
+	upgrade := newTestUpgrade(t, rootDir)
+	now := time.Date(2024, 11, 5, 9, 30, 15, 0, time.UTC)
+	BranchTemplate, GroupBy = defaultBranchTemplate, ciGroupByAll
+
+	var out bytes.Buffer
+	require.NoError(t, dryRunModuleUpdates(context.Background(), upgrade, now, &out))
+	assert.Equal(t, "--- a/main.tf\n+++ b/main.tf\n@@ -1,7 +1,7 @@\n module \"vpc\" {\n"+
+		"-  source = \"git::https://github.com/org/vpc.git?ref=v1.0.0\"\n+  source = \"git::https://github.com/org/vpc.git?ref=v2.0.0\"\n }\n \n module \"eks\" {\n"+
+		"-  source = \"git::https://github.com/org/eks.git?ref=v1.0.0\"\n+  source = \"git::https://github.com/org/eks.git?ref=v1.1.0\"\n }\n", out.String())
+	content, err := os.ReadFile(rootDir + "/main.tf")
+	require.NoError(t, err)
+	assert.Equal(t, testModulesTf, string(content), "dry run wrote to the file")
+
+	PlanFile, GroupBy = rootDir+"/plan.json", ciGroupBySeverity
+	require.NoError(t, dryRunModuleUpdates(context.Background(), upgrade, now, &bytes.Buffer{}))
+	planJson, err := os.ReadFile(PlanFile)
+	require.NoError(t, err)
+	var plan upgradePlan
+	require.NoError(t, json.Unmarshal(planJson, &plan))
+	assert.Equal(t, []planChange{
+		{File: "main.tf", Module: "vpc", Source: "https://github.com/org/vpc.git", From: "v1.0.0", To: "v2.0.0", MajorUpgrade: true, Branch: "upgrade/tf-modules-2024-11-05-major"},
+		{File: "main.tf", Module: "eks", Source: "https://github.com/org/eks.git", From: "v1.0.0", To: "v1.1.0", Branch: "upgrade/tf-modules-2024-11-05-minor"},
+	}, plan.Changes)
+
+	PlanFile = "-"
+	out.Reset()
+	require.NoError(t, dryRunModuleUpdates(context.Background(), upgrade, now, &out))
+	assert.Contains(t, out.String(), "+++ b/main.tf\n")
+	assert.Contains(t, out.String(), "}\n{\n  \"changes\": [\n", "plan not printed after the diff")
+}
+
+func TestDryRunModuleUpdatesFormatted(t *testing.T) {
+	rootDir := t.TempDir()
+	upgrade := newTestUpgrade(t, rootDir)
+	unformattedTf := "module \"vpc\" {\nsource=\"git::https://github.com/org/vpc.git?ref=v1.0.0\"\n}\n"
+	require.NoError(t, os.WriteFile(rootDir+"/main.tf", []byte(unformattedTf), os.ModePerm))
+	upgrade.format = func(ctx context.Context, content []byte) ([]byte, error) {
+		return hclwrite.Format(content), nil
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, dryRunModuleUpdates(context.Background(), upgrade, time.Now(), &out))
+	assert.Contains(t, out.String(), "+  source = \"git::https://github.com/org/vpc.git?ref=v2.0.0\"\n", "diff not formatted like a real run")
+	content, err := os.ReadFile(rootDir + "/main.tf")
+	require.NoError(t, err)
+	assert.Equal(t, unformattedTf, string(content), "dry run wrote to the file")
+}
@@ -1,4 +1,17 @@
+// Package errorHandlers holds the error messages and exit codes of the commands. The stages and error codes of failed
+// module lookups live in pkg/failure instead, since the resolver sets them and can't import cmd.
 package errorHandlers
 
-const CheckOutputFormatError = "output format not supported. Please use csv or json"
-const CloningErrorPrefix = "unable to clone repo "
+const CheckOutputFormatError = "output format not supported. Please use csv, json, markdown, sarif, junit or html"
+const FailOnError = "fail-on condition not supported. Please use any, minor, major or failure"
+const MarkdownGroupByError = "markdown grouping not supported. Please use file or repo"
+const ReportErrorPrefix = "unable to write report "
+const ScanningErrorPrefix = "unable to scan "
+const TagRulesErrorPrefix = "invalid tag_rules config "
+const BranchTemplateErrorPrefix = "invalid branch template "
+const CommitErrorPrefix = "unable to commit module updates "
+const PushErrorPrefix = "unable to push "
+const PullRequestErrorPrefix = "unable to open pull request "
+const CiGroupByError = "ci grouping not supported. Please use all, repo, directory or severity"
+const DryRunErrorPrefix = "unable to show dry run "
+const CacheDirError = "unable to find the cache directory, set cache_dir in the config"
@@ -0,0 +1,16 @@
+package errorHandlers
+
+// Exit codes of fatal errors, the scan or the reports could not be completed or the flags are invalid
+const ExitCodeError = 1
+const ExitCodeUsage = 2
+
+// Exit codes of checkForUpdates when a --fail-on condition is met. Lookup failures take precedence over outdated
+// modules, which exit with the code of the most severe update found.
+const ExitCodeLookupFailure = 10
+const ExitCodePatchUpdate = 11
+const ExitCodeMinorUpdate = 12
+const ExitCodeMajorUpdate = 13
+
+// Exit code of checkForUpdates when --require-pinned is set and module blocks aren't pinned to a version. Lookup
+// failures take precedence, outdated modules don't.
+const ExitCodeUnpinned = 14
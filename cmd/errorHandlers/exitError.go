@@ -0,0 +1,23 @@
+package errorHandlers
+
+// ExitError is returned by commands to exit with Code, Err is printed unless it is nil
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return ""
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// UsageError exits with ExitCodeUsage
+func UsageError(err error) error {
+	return &ExitError{Code: ExitCodeUsage, Err: err}
+}
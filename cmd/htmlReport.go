@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	_ "embed"
+	"html/template"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/rs/zerolog/log"
+	"github.com/thundersparkf/samwise/pkg/resolver"
+)
+
+const htmlExtension = "html"
+
+// Self-contained page(inline CSS/JS, no network access needed) the html report is rendered with
+//
+//go:embed templates/htmlReport.html
+var htmlReportTemplate string
+
+type htmlReportData struct {
+	GeneratedAt   string
+	Totals        htmlReportTotals
+	Repos         []htmlRepoSummary
+	Modules       []htmlModuleRow
+	MajorUpgrades []htmlModuleRow
+	Unpinned      []htmlModuleRow
+	Failures      []htmlModuleRow
+}
+
+type htmlReportTotals struct {
+	Modules            int
+	Sources            int
+	Files              int
+	ModulesWithUpdates int
+	MajorUpgrades      int
+	Unpinned           int
+	Failures           int
+}
+
+// Adoption of a module source across files and the spread of versions in use
+type htmlRepoSummary struct {
+	Repo          string
+	Blocks        int
+	Files         []string
+	Versions      []htmlVersionCount
+	LatestVersion string
+}
+
+type htmlVersionCount struct {
+	Version string
+	Count   int
+}
+
+type htmlModuleRow struct {
+	Name string
+	Repo string
+	// Repo with the submodule(the source as written for failures) and <file_name>:<line> of the module block
+	Source         string
+	Location       string
+	CurrentVersion string
+	LatestVersion  string
+	FileName       string
+	Severity       string
+	Pinning        string
+	// Where the commit or branch of unpinned module blocks stands in the history of their source
+	RefHistory       string
+	UpdatesAvailable []string
+	Error            string
+	ErrorStage       string
+	ErrorCode        string
+}
+
+func createHTMLReportFile(data []UpdateResult, failureList []UpdateResult, path string, filename string) error {
+	report, reportFilePath, err := createReportWriter(path, filename, htmlExtension)
+	if err != nil {
+		return err
+	}
+	err = writeHTMLReport(data, failureList, report, reportFilePath)
+	return closeReportWriter(report, reportFilePath, err)
+}
+
+// Sorts versions newest first, falling back to string order for the ones that aren't versions
+func sortVersionCounts(versions []htmlVersionCount) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		left, leftErr := version.NewVersion(versions[i].Version)
+		right, rightErr := version.NewVersion(versions[j].Version)
+		if leftErr == nil && rightErr == nil {
+			return left.GreaterThan(right)
+		}
+		if leftErr == nil || rightErr == nil {
+			return leftErr == nil
+		}
+		return versions[i].Version < versions[j].Version
+	})
+}
+
+// Summarises the same module rows the csv report is written from
+func getHTMLReportData(data []UpdateResult, failureList []UpdateResult) htmlReportData {
+	reportData := htmlReportData{GeneratedAt: time.Now().Format(time.RFC1123)}
+	repos := make(map[string]*htmlRepoSummary)
+	repoVersions := make(map[string]map[string]int)
+	repoFiles := make(map[string]map[string]bool)
+	files := make(map[string]bool)
+	for _, module := range data {
+		repo := module.Repo
+		row := htmlModuleRow{
+			Name:             module.Name,
+			Repo:             repo,
+			Source:           module.SourceWithSubmodule(),
+			Location:         module.Location(),
+			CurrentVersion:   module.CurrentVersion,
+			LatestVersion:    module.LatestVersion,
+			FileName:         module.FileName,
+			Severity:         module.UpgradeSeverity,
+			Pinning:          module.Pinning,
+			RefHistory:       getRefDescription(module),
+			UpdatesAvailable: module.UpdatesAvailable,
+			Error:            module.Error,
+		}
+		reportData.Modules = append(reportData.Modules, row)
+		if module.HasUpdates() {
+			reportData.Totals.ModulesWithUpdates++
+		}
+		if module.IsMajorUpgrade {
+			reportData.MajorUpgrades = append(reportData.MajorUpgrades, row)
+		}
+		if module.IsUnpinned() {
+			reportData.Unpinned = append(reportData.Unpinned, row)
+		}
+		files[row.FileName] = true
+
+		summary, ok := repos[repo]
+		if !ok {
+			summary = &htmlRepoSummary{Repo: repo}
+			repos[repo] = summary
+			repoVersions[repo] = make(map[string]int)
+			repoFiles[repo] = make(map[string]bool)
+		}
+		summary.Blocks++
+		repoVersions[repo][row.CurrentVersion]++
+		if !repoFiles[repo][row.FileName] {
+			repoFiles[repo][row.FileName] = true
+			summary.Files = append(summary.Files, row.FileName)
+		}
+		if summary.LatestVersion == "" || resolver.IsNewer(summary.LatestVersion, row.LatestVersion) {
+			summary.LatestVersion = row.LatestVersion
+		}
+	}
+	for repo, summary := range repos {
+		for currentVersion, count := range repoVersions[repo] {
+			summary.Versions = append(summary.Versions, htmlVersionCount{Version: currentVersion, Count: count})
+		}
+		sortVersionCounts(summary.Versions)
+		sort.Strings(summary.Files)
+		reportData.Repos = append(reportData.Repos, *summary)
+	}
+	sort.Slice(reportData.Repos, func(i, j int) bool {
+		if reportData.Repos[i].Blocks != reportData.Repos[j].Blocks {
+			return reportData.Repos[i].Blocks > reportData.Repos[j].Blocks
+		}
+		return reportData.Repos[i].Repo < reportData.Repos[j].Repo
+	})
+	for _, failure := range failureList {
+		reportData.Failures = append(reportData.Failures, htmlModuleRow{
+			Name:           failure.Name,
+			Repo:           failure.Repo,
+			Source:         failureSource(failure),
+			Location:       failure.Location(),
+			CurrentVersion: failure.CurrentVersion,
+			FileName:       failure.FileName,
+			Error:          failure.Error,
+			ErrorStage:     string(failure.ErrorStage),
+			ErrorCode:      string(failure.ErrorCode),
+		})
+	}
+	reportData.Totals.Modules = len(reportData.Modules)
+	reportData.Totals.Sources = len(reportData.Repos)
+	reportData.Totals.Files = len(files)
+	reportData.Totals.MajorUpgrades = len(reportData.MajorUpgrades)
+	reportData.Totals.Unpinned = len(reportData.Unpinned)
+	reportData.Totals.Failures = len(reportData.Failures)
+	return reportData
+}
+
+func writeHTMLReport(data []UpdateResult, failureList []UpdateResult, report io.Writer, reportFilePath string) error {
+	reportTemplate, err := template.New("htmlReport").Parse(htmlReportTemplate)
+	if err == nil {
+		err = reportTemplate.Execute(report, getHTMLReportData(data, failureList))
+	}
+	if err != nil {
+		return newReportError(reportFilePath, err)
+	}
+	log.Debug().Msgf("created " + reportFilePath)
+	return nil
+}
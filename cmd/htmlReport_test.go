@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thundersparkf/samwise/pkg/resolver"
+	"github.com/thundersparkf/samwise/pkg/scanner"
+)
+
+func TestGetHTMLReportData(t *testing.T) {
+	var data []UpdateResult
+	for _, module := range []struct{ currentVersion, fileName string }{{"v1.2.0", "infra/main.tf"}, {"v1.3.0", "app/main.tf"}, {"v1.10.0", "app/main.tf"}} {
+		vpc := newUpdateResult("github.com/org/vpc", module.currentVersion, module.fileName, "v2.0.0")
+		vpc.UpgradeSeverity, vpc.IsMajorUpgrade = resolver.SeverityMajor, true
+		data = append(data, vpc)
+	}
+	data[0].UpdatesAvailable = []string{"v1.3.0", "v2.0.0"}
+	s3 := newUpdateResult("github.com/org/s3", "main", "app/main.tf")
+	s3.Pinning = scanner.PinningBranch
+	data = append(data, s3)
+	failureList := []UpdateResult{
+		{ModuleUsage: ModuleUsage{Name: "missing", Repo: "github.com/org/missing", Submodule: "modules/bucket", CurrentVersion: "v0.1.0", FileName: "app/main.tf", Line: 12}, Error: "not found"},
+	}
+	reportData := getHTMLReportData(data, failureList)
+	assert.Equal(t, htmlReportTotals{Modules: 4, Sources: 2, Files: 2, ModulesWithUpdates: 3, MajorUpgrades: 3, Unpinned: 1, Failures: 1}, reportData.Totals)
+	assert.Equal(t, 2, len(reportData.Repos))
+
+	vpc := reportData.Repos[0]
+	assert.Equal(t, "github.com/org/vpc", vpc.Repo)
+	assert.Equal(t, 3, vpc.Blocks)
+	assert.Equal(t, []string{"app/main.tf", "infra/main.tf"}, vpc.Files)
+	assert.Equal(t, []htmlVersionCount{{"v1.10.0", 1}, {"v1.3.0", 1}, {"v1.2.0", 1}}, vpc.Versions)
+	assert.Equal(t, "v2.0.0", vpc.LatestVersion)
+	assert.Equal(t, "", reportData.Repos[1].LatestVersion)
+
+	assert.Equal(t, []string{"v1.3.0", "v2.0.0"}, reportData.Modules[0].UpdatesAvailable)
+	assert.Equal(t, "v2.0.0", reportData.Modules[0].LatestVersion)
+	assert.Equal(t, "not found", reportData.Failures[0].Error)
+	assert.Equal(t, "github.com/org/missing//modules/bucket", reportData.Failures[0].Source)
+	assert.Equal(t, "app/main.tf:12", reportData.Failures[0].Location)
+	assert.Equal(t, 1, len(reportData.Unpinned))
+	assert.Equal(t, "branch", reportData.Unpinned[0].Pinning)
+}
+
+func TestWriteHTMLReport(t *testing.T) {
+	vpc := newUpdateResult("github.com/org/<vpc>", "v1.2.0", "main.tf", "v2.0.0")
+	vpc.Name, vpc.Line = "vpc", 4
+	vpc.UpgradeSeverity, vpc.IsMajorUpgrade = resolver.SeverityMajor, true
+	var report bytes.Buffer
+	require.NoError(t, writeHTMLReport([]UpdateResult{vpc}, nil, &report, "buffer"))
+	content := report.String()
+	assert.Contains(t, content, "<!DOCTYPE html>")
+	assert.Contains(t, content, "github.com/org/&lt;vpc&gt;", "module source not escaped")
+	assert.Contains(t, content, `<span class="severity major">major</span>`)
+	assert.Contains(t, content, "<td><code>vpc</code></td>")
+	assert.Contains(t, content, "<td>main.tf:4</td>")
+	assert.Contains(t, content, "Every file was scanned and every module source was looked up.")
+	assert.NotContains(t, content, "http://", "report depends on the network")
+	assert.NotContains(t, content, "src=")
+}
+
+func TestGenerateHTMLReport(t *testing.T) {
+	outputDir := t.TempDir()
+	require.NoError(t, generateReport(nil, nil, "module_report", "html", outputDir))
+	content, err := os.ReadFile(outputDir + "/module_report.html")
+	assert.Empty(t, err)
+	assert.Contains(t, string(content), "No module is a major version behind.")
+}
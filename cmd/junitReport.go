@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/thundersparkf/samwise/pkg/failure"
+)
+
+const junitExtension = "xml"
+
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Errors     int              `xml:"errors,attr"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func createJUnitReportFile(data []UpdateResult, failureList []UpdateResult, path string, filename string) error {
+	report, reportFilePath, err := createReportWriter(path, filename, junitExtension)
+	if err != nil {
+		return err
+	}
+	err = writeJUnitReport(data, failureList, report, reportFilePath)
+	return closeReportWriter(report, reportFilePath, err)
+}
+
+// Returns the test case of a module block, failing when updates are available or it isn't pinned to a version and erroring with the error code as its
+// type when it failed(the file when it could not be scanned)
+func getJUnitTestCase(module UpdateResult) junitTestCase {
+	testCase := junitTestCase{Name: module.SourceWithSubmodule(), ClassName: module.FileName}
+	if module.CurrentVersion != "" {
+		testCase.Name += "@" + module.CurrentVersion
+	}
+	if module.Name != "" {
+		testCase.Name = module.Address() + " (" + testCase.Name + ")"
+	}
+	if module.Error != "" {
+		problem := junitProblem{Message: "unable to look up " + module.Repo, Type: "lookup", Text: module.Error}
+		if module.ErrorCode != "" {
+			problem.Type = string(module.ErrorCode)
+		}
+		if module.ErrorStage == failure.StageParse {
+			testCase.Name, problem.Message = module.FileName, "unable to scan "+module.FileName
+		}
+		testCase.Error = &problem
+		return testCase
+	}
+	if module.IsUnpinned() {
+		testCase.Failure = &junitProblem{
+			Message: module.Repo + " is not pinned to a version(" + getPinningDescription(module) + ")",
+			Type:    "unpinned",
+			Text:    "latest version: " + module.LatestVersion,
+		}
+		return testCase
+	}
+	if module.CurrentVersion == "" || !module.HasUpdates() {
+		return testCase
+	}
+	failureType := "outdated"
+	if module.UpgradeSeverity != "" {
+		failureType = module.UpgradeSeverity
+	}
+	testCase.Failure = &junitProblem{
+		Message: module.Repo + " " + module.CurrentVersion + " is outdated, " + module.LatestVersion + " is available",
+		Type:    failureType,
+		Text:    "newer tags: " + strings.Join(module.UpdatesAvailable, ", "),
+	}
+	return testCase
+}
+
+// Writes every scanned directory as a test suite with a test case per module block. Lookup failures are read off the
+// module blocks themselves, the failure list only carries one entry per source.
+func writeJUnitReport(data []UpdateResult, failureList []UpdateResult, report io.Writer, reportFilePath string) error {
+	log.Debug().Msgf("junitReport :: writeJUnitReport :: failures :: %d", len(failureList))
+	suites := make(map[string]*junitTestSuite)
+	var suiteNames []string
+	for _, module := range data {
+		directory := filepath.Dir(module.FileName)
+		suite, ok := suites[directory]
+		if !ok {
+			suite = &junitTestSuite{Name: directory}
+			suites[directory] = suite
+			suiteNames = append(suiteNames, directory)
+		}
+		testCase := getJUnitTestCase(module)
+		suite.Tests++
+		if testCase.Failure != nil {
+			suite.Failures++
+		}
+		if testCase.Error != nil {
+			suite.Errors++
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	sort.Strings(suiteNames)
+
+	testSuites := junitTestSuites{Name: "samwise"}
+	for _, suiteName := range suiteNames {
+		suite := suites[suiteName]
+		testSuites.Tests += suite.Tests
+		testSuites.Failures += suite.Failures
+		testSuites.Errors += suite.Errors
+		testSuites.TestSuites = append(testSuites.TestSuites, *suite)
+	}
+	reportString, err := xml.MarshalIndent(testSuites, "", "  ")
+	if err == nil {
+		_, err = report.Write([]byte(xml.Header + string(reportString) + "\n"))
+	}
+	if err != nil {
+		return newReportError(reportFilePath, err)
+	}
+	log.Debug().Msgf("created " + reportFilePath)
+	return nil
+}
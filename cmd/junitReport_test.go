@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thundersparkf/samwise/pkg/resolver"
+	"github.com/thundersparkf/samwise/pkg/scanner"
+)
+
+func TestWriteJUnitReport(t *testing.T) {
+	vpc := newUpdateResult("github.com/org/vpc", "v1.2.0", "infra/main.tf", "v1.3.0", "v2.0.0")
+	vpc.Name, vpc.Submodule = "vpc", "modules/subnets"
+	vpc.UpgradeSeverity, vpc.IsMajorUpgrade = resolver.SeverityMajor, true
+	missing := newUpdateResult("github.com/org/missing", "v0.1.0", "app/main.tf")
+	missing.Error = "not found"
+	unpinned := UpdateResult{ModuleUsage: ModuleUsage{Repo: "github.com/org/eks", Pinning: scanner.PinningNone, FileName: "infra/eks.tf"}, LatestVersion: "v1.1.0"}
+	data := []UpdateResult{vpc, newUpdateResult("github.com/org/s3", "v1.0.0", "infra/s3.tf"), missing, unpinned}
+	var report bytes.Buffer
+	require.NoError(t, writeJUnitReport(data, []UpdateResult{missing}, &report, "buffer"))
+	assert.True(t, strings.HasPrefix(report.String(), xml.Header))
+
+	var testSuites junitTestSuites
+	err := xml.Unmarshal(report.Bytes(), &testSuites)
+	assert.Empty(t, err)
+	assert.Equal(t, 4, testSuites.Tests)
+	assert.Equal(t, 2, testSuites.Failures)
+	assert.Equal(t, 1, testSuites.Errors)
+	assert.Equal(t, 2, len(testSuites.TestSuites))
+
+	app, infra := testSuites.TestSuites[0], testSuites.TestSuites[1]
+	assert.Equal(t, "app", app.Name)
+	assert.Equal(t, 1, app.Errors)
+	assert.Equal(t, "not found", app.TestCases[0].Error.Text)
+	assert.Nil(t, app.TestCases[0].Failure)
+
+	assert.Equal(t, "infra", infra.Name)
+	assert.Equal(t, 3, infra.Tests)
+	assert.Equal(t, 2, infra.Failures)
+	vpcCase := infra.TestCases[0]
+	assert.Equal(t, "module.vpc (github.com/org/vpc//modules/subnets@v1.2.0)", vpcCase.Name)
+	assert.Equal(t, "github.com/org/s3@v1.0.0", infra.TestCases[1].Name)
+	assert.Equal(t, "infra/main.tf", vpcCase.ClassName)
+	assert.Equal(t, "major", vpcCase.Failure.Type)
+	assert.Equal(t, "github.com/org/vpc v1.2.0 is outdated, v2.0.0 is available", vpcCase.Failure.Message)
+	assert.Equal(t, "newer tags: v1.3.0, v2.0.0", vpcCase.Failure.Text)
+	assert.Nil(t, infra.TestCases[1].Failure, "up to date module failed")
+	assert.Nil(t, infra.TestCases[1].Error)
+	assert.Equal(t, "unpinned", infra.TestCases[2].Failure.Type)
+	assert.Equal(t, "github.com/org/eks is not pinned to a version(no ref or version)", infra.TestCases[2].Failure.Message)
+}
+
+func TestGenerateJUnitReport(t *testing.T) {
+	outputDir := t.TempDir()
+	data := []UpdateResult{newUpdateResult("github.com/test_repo", "2.4.4", "main.tf", "2.7.7", "2.7.8")}
+	require.NoError(t, generateReport(data, nil, "module_report", "junit", outputDir))
+	content, err := os.ReadFile(outputDir + "/module_report.xml")
+	assert.Empty(t, err)
+	assert.Contains(t, string(content), `<testsuites name="samwise" tests="1" failures="1" errors="0">`)
+}
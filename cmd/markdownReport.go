@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/thundersparkf/samwise/cmd/errorHandlers"
+)
+
+const markdownExtension = "md"
+const markdownGroupByFile = "file"
+const markdownGroupByRepo = "repo"
+
+var MarkdownGroupBy string
+
+func checkMarkdownGroupBy(groupBy string) (string, error) {
+	groupBy = strings.ToLower(strings.TrimSpace(groupBy))
+	if !slices.Contains([]string{markdownGroupByFile, markdownGroupByRepo}, groupBy) {
+		return "", errors.New(errorHandlers.MarkdownGroupByError)
+	}
+	return groupBy, nil
+}
+
+func createMarkdownReportFile(data []UpdateResult, failureList []UpdateResult, path string, filename string) error {
+	log.Debug().Msgf("creating " + path + "/" + filename + "." + markdownExtension + " file")
+	report, reportFilePath, err := createReportWriter(path, filename, markdownExtension)
+	if err != nil {
+		return err
+	}
+	err = writeMarkdownReport(data, failureList, MarkdownGroupBy, report, reportFilePath)
+	return closeReportWriter(report, reportFilePath, err)
+}
+
+// Escapes the characters that would break a markdown table cell
+func escapeMarkdownCell(value string) string {
+	value = strings.ReplaceAll(value, "|", "\\|")
+	return strings.ReplaceAll(value, "\n", " ")
+}
+
+func markdownCode(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "`" + escapeMarkdownCell(value) + "`"
+}
+
+func writeMarkdownTable(report io.Writer, headers []string, rows [][]string) error {
+	lines := []string{"| " + strings.Join(headers, " | ") + " |", "|" + strings.Repeat(" --- |", len(headers))}
+	for _, row := range rows {
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+	}
+	_, err := fmt.Fprint(report, strings.Join(lines, "\n")+"\n\n")
+	return err
+}
+
+// Writes the modules with updates as tables grouped by file or repo, followed by the unpinned modules and the failures.
+// Meant to be posted
+// as a pull/merge request comment.
+func writeMarkdownReport(data []UpdateResult, failureList []UpdateResult, groupBy string, report io.Writer, reportFilePath string) error {
+	groups := make(map[string][][]string)
+	var groupNames []string
+	modulesWithUpdates, majorUpgrades := 0, 0
+	for _, row := range data {
+		if row.CurrentVersion == "" || !row.HasUpdates() {
+			continue
+		}
+		modulesWithUpdates++
+		majorMarker := ""
+		if row.IsMajorUpgrade {
+			majorMarker = ":warning: major"
+			majorUpgrades++
+		}
+		newerTags := markdownCode(strings.Join(row.UpdatesAvailable, ", "))
+		// Blocks are told apart by name and line when several use the same source
+		group, columns := row.FileName, []string{markdownCode(row.Name), markdownCode(row.SourceWithSubmodule()), formatPosition(row.Line)}
+		if groupBy == markdownGroupByRepo {
+			group, columns = row.Repo, []string{markdownCode(row.Location()), markdownCode(row.Name), markdownCode(row.Submodule)}
+		}
+		if _, ok := groups[group]; !ok {
+			groupNames = append(groupNames, group)
+		}
+		groups[group] = append(groups[group], append(columns, markdownCode(row.CurrentVersion), markdownCode(row.LatestVersion), majorMarker, newerTags))
+	}
+	sort.Strings(groupNames)
+
+	summary := strconv.Itoa(modulesWithUpdates) + " module(s) with updates available"
+	if majorUpgrades > 0 {
+		summary += ", " + strconv.Itoa(majorUpgrades) + " of them major"
+	}
+	unpinned := getUnpinnedModules(data)
+	if len(unpinned) > 0 {
+		summary += ", " + strconv.Itoa(len(unpinned)) + " not pinned to a version"
+	}
+	if len(failureList) > 0 {
+		summary += ", " + strconv.Itoa(len(failureList)) + " failure(s)"
+	}
+	_, err := fmt.Fprint(report, "## Terraform module updates\n\n"+summary+".\n\n")
+	if err != nil {
+		return newReportError(reportFilePath, err)
+	}
+
+	headers := []string{"Module", "Source", "Line"}
+	if groupBy == markdownGroupByRepo {
+		headers = []string{"Location", "Module", "Submodule"}
+	}
+	headers = append(headers, "Current", "Latest", "Major upgrade", "Newer tags")
+	for _, group := range groupNames {
+		_, err = fmt.Fprint(report, "### "+markdownCode(group)+"\n\n")
+		if err == nil {
+			err = writeMarkdownTable(report, headers, groups[group])
+		}
+		if err != nil {
+			return newReportError(reportFilePath, err)
+		}
+	}
+
+	if len(unpinned) > 0 {
+		var rows [][]string
+		for _, module := range unpinned {
+			rows = append(rows, []string{markdownCode(module.Name), markdownCode(module.SourceWithSubmodule()), markdownCode(module.Location()), module.Pinning, markdownCode(module.CurrentVersion), getRefDescription(module), markdownCode(module.LatestVersion)})
+		}
+		_, err = fmt.Fprint(report, "### Unpinned\n\n")
+		if err == nil {
+			err = writeMarkdownTable(report, []string{"Module", "Source", "Location", "Pinning", "Ref", "History", "Latest"}, rows)
+		}
+		if err != nil {
+			return newReportError(reportFilePath, err)
+		}
+	}
+
+	if len(failureList) > 0 {
+		var rows [][]string
+		for _, failure := range failureList {
+			rows = append(rows, []string{markdownCode(failure.Name), markdownCode(failureSource(failure)), markdownCode(failure.Location()), markdownCode(failure.CurrentVersion),
+				string(failure.ErrorStage), markdownCode(string(failure.ErrorCode)), escapeMarkdownCell(failure.Error)})
+		}
+		_, err = fmt.Fprint(report, "### Failures\n\n")
+		if err == nil {
+			err = writeMarkdownTable(report, []string{"Module", "Source", "Location", "Version", "Stage", "Code", "Error"}, rows)
+		}
+		if err != nil {
+			return newReportError(reportFilePath, err)
+		}
+	}
+	log.Debug().Msgf("created " + reportFilePath)
+	return nil
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thundersparkf/samwise/cmd/errorHandlers"
+	"github.com/thundersparkf/samwise/pkg/failure"
+	"github.com/thundersparkf/samwise/pkg/resolver"
+	"github.com/thundersparkf/samwise/pkg/scanner"
+)
+
+func TestCheckMarkdownGroupBy(t *testing.T) {
+	groupBy, err := checkMarkdownGroupBy("Repo")
+	assert.Empty(t, err)
+	assert.Equal(t, "repo", groupBy)
+	groupBy, err = checkMarkdownGroupBy("file")
+	assert.Empty(t, err)
+	assert.Equal(t, "file", groupBy)
+	_, err = checkMarkdownGroupBy("module")
+	assert.Equal(t, errors.New(errorHandlers.MarkdownGroupByError), err)
+}
+
+func TestWriteMarkdownReport(t *testing.T) {
+	vpc := newUpdateResult("github.com/org/vpc", "v1.2.0", "main.tf", "v1.3.0", "v2.0.0")
+	vpc.Name, vpc.Submodule, vpc.Line = "vpc", "modules/subnets", 7
+	vpc.UpgradeSeverity, vpc.IsMajorUpgrade = resolver.SeverityMajor, true
+	eks := newUpdateResult("terraform-aws-modules/eks/aws", "~> 4.2", "eks/main.tf", "4.3.0")
+	eks.UpgradeSeverity = resolver.SeverityMinor
+	data := []UpdateResult{vpc, eks, newUpdateResult("github.com/org/s3", "v1.0.0", "main.tf")}
+	failureList := []UpdateResult{
+		{ModuleUsage: ModuleUsage{Name: "missing", Source: "git::github.com/org/missing?ref=v0.1.0", Repo: "github.com/org/missing", CurrentVersion: "v0.1.0", FileName: "app.tf", Line: 2},
+			Error: "unable to list remote tags | not found", ErrorStage: failure.StageNetwork, ErrorCode: failure.ErrorCodeNotFound},
+	}
+	var report bytes.Buffer
+	require.NoError(t, writeMarkdownReport(data, failureList, markdownGroupByFile, &report, "buffer"))
+	content := report.String()
+	assert.Contains(t, content, "2 module(s) with updates available, 1 of them major, 1 failure(s).")
+	assert.Contains(t, content, "### `main.tf`\n\n| Module | Source | Line | Current | Latest | Major upgrade | Newer tags |\n| --- | --- | --- | --- | --- | --- | --- |\n| `vpc` | `github.com/org/vpc//modules/subnets` | 7 | `v1.2.0` | `v2.0.0` | :warning: major | `v1.3.0, v2.0.0` |\n")
+	assert.Contains(t, content, "|  | `terraform-aws-modules/eks/aws` |  | `~> 4.2` | `4.3.0` |  | `4.3.0` |")
+	assert.NotContains(t, content, "github.com/org/s3", "module without updates in report")
+	assert.Less(t, strings.Index(content, "`eks/main.tf`"), strings.Index(content, "`main.tf`"), "groups not sorted")
+	assert.Contains(t, content, "### Failures\n\n| Module | Source | Location | Version | Stage | Code | Error |\n| --- | --- | --- | --- | --- | --- | --- |\n| `missing` | `git::github.com/org/missing?ref=v0.1.0` | `app.tf:2` | `v0.1.0` | network | `E_NOT_FOUND` | unable to list remote tags \\| not found |\n")
+
+	report.Reset()
+	require.NoError(t, writeMarkdownReport(data, nil, markdownGroupByRepo, &report, "buffer"))
+	content = report.String()
+	assert.Contains(t, content, "### `github.com/org/vpc`\n\n| Location | Module | Submodule | Current | Latest | Major upgrade | Newer tags |")
+	assert.Contains(t, content, "| `main.tf:7` | `vpc` | `modules/subnets` | `v1.2.0` | `v2.0.0` | :warning: major | `v1.3.0, v2.0.0` |")
+	assert.NotContains(t, content, "Failures")
+	assert.NotContains(t, content, "Unpinned")
+
+	report.Reset()
+	unpinned := UpdateResult{ModuleUsage: ModuleUsage{Name: "eks", Source: "github.com/org/eks?ref=main", Repo: "github.com/org/eks", CurrentVersion: "main", Pinning: scanner.PinningBranch, FileName: "main.tf", Line: 9}, LatestVersion: "v1.1.0",
+		Ref: &resolver.RefInfo{Commit: "48fcbf3e1f5b4c3d", DefaultBranch: "master", CommitsBehind: 3, TagsBehind: 1}}
+	require.NoError(t, writeMarkdownReport(append(data, unpinned), nil, markdownGroupByFile, &report, "buffer"))
+	content = report.String()
+	assert.Contains(t, content, "2 module(s) with updates available, 1 of them major, 1 not pinned to a version.")
+	assert.Contains(t, content, "### Unpinned\n\n| Module | Source | Location | Pinning | Ref | History | Latest |\n| --- | --- | --- | --- | --- | --- | --- |\n| `eks` | `github.com/org/eks` | `main.tf:9` | branch | `main` | at 48fcbf3, 3 commit(s) and 1 tag(s) behind master, not released yet | `v1.1.0` |\n")
+}
+
+func TestGenerateMarkdownReport(t *testing.T) {
+	outputDir := t.TempDir()
+	data := []UpdateResult{newUpdateResult("github.com/test_repo", "2.4.4", "main.tf", "2.7.7", "2.7.8")}
+	require.NoError(t, generateReport(data, nil, "module_report", "markdown", outputDir))
+	content, err := os.ReadFile(outputDir + "/module_report.md")
+	assert.Empty(t, err)
+	assert.Contains(t, string(content), "|  | `github.com/test_repo` |  | `2.4.4` | `2.7.8` |  | `2.7.7, 2.7.8` |")
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/thundersparkf/samwise/cmd/errorHandlers"
+	"github.com/thundersparkf/samwise/pkg/resolver"
+)
+
+const ciGroupByAll = "all"
+const ciGroupByRepo = "repo"
+const ciGroupByDirectory = "directory"
+const ciGroupBySeverity = "severity"
+
+var GroupBy string
+
+var branchSlugRegex = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Module bumps committed to the same upgrade branch
+type bumpGroup struct {
+	// Told apart from the other groups of the run by it, empty for --group-by=all
+	Key string
+	// Tail of the commit message summary, e.g. from github.com/org/vpc
+	Description string
+	Bumps       []moduleBump
+}
+
+func checkCiGroupBy(groupBy string) (string, error) {
+	groupBy = strings.ToLower(strings.TrimSpace(groupBy))
+	if !slices.Contains([]string{ciGroupByAll, ciGroupByRepo, ciGroupByDirectory, ciGroupBySeverity}, groupBy) {
+		return "", errors.New(errorHandlers.CiGroupByError)
+	}
+	return groupBy, nil
+}
+
+// Key and description of the group of the bump: its upstream repo, its directory or its severity
+func getBumpGroup(bump moduleBump, groupBy string, rootDir string) (string, string) {
+	switch groupBy {
+	case ciGroupByRepo:
+		repo := resolver.NormalizeRepoUrl(bump.Repo)
+		return repo, "from " + repo
+	case ciGroupByDirectory:
+		directory, err := filepath.Rel(rootDir, filepath.Dir(bump.FileName))
+		if err != nil {
+			directory = filepath.Dir(bump.FileName)
+		}
+		directory = filepath.ToSlash(directory)
+		if directory == "." {
+			return directory, "in the root directory"
+		}
+		return directory, "in " + directory
+	case ciGroupBySeverity:
+		if bump.IsMajorUpgrade {
+			return resolver.SeverityMajor, "with major upgrades"
+		}
+		return resolver.SeverityMinor, "with minor and patch upgrades"
+	}
+	return "", ""
+}
+
+// Splits the bumps into the groups of --group-by ordered by key, keeping the order of the bumps within each
+func groupModuleBumps(bumps []moduleBump, groupBy string, rootDir string) []bumpGroup {
+	var groups []bumpGroup
+	for _, bump := range bumps {
+		key, description := getBumpGroup(bump, groupBy, rootDir)
+		i := slices.IndexFunc(groups, func(group bumpGroup) bool { return group.Key == key })
+		if i < 0 {
+			groups = append(groups, bumpGroup{Key: key, Description: description})
+			i = len(groups) - 1
+		}
+		groups[i].Bumps = append(groups[i].Bumps, bump)
+	}
+	slices.SortStableFunc(groups, func(a, b bumpGroup) int { return strings.Compare(a.Key, b.Key) })
+	return groups
+}
+
+// Part of the branch name telling the group apart, e.g. github.com-org-vpc or infra-prod
+func getBranchSlug(key string) string {
+	slug := strings.Trim(branchSlugRegex.ReplaceAllString(key, "-"), "-.")
+	if slug == "" {
+		return "root"
+	}
+	return slug
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thundersparkf/samwise/cmd/errorHandlers"
+)
+
+func TestCheckCiGroupBy(t *testing.T) {
+	groupBy, err := checkCiGroupBy("Repo")
+	require.NoError(t, err)
+	assert.Equal(t, ciGroupByRepo, groupBy)
+	groupBy, err = checkCiGroupBy(" severity ")
+	require.NoError(t, err)
+	assert.Equal(t, ciGroupBySeverity, groupBy)
+	_, err = checkCiGroupBy("module")
+	assert.EqualError(t, err, errorHandlers.CiGroupByError)
+}
+
+func TestGroupModuleBumps(t *testing.T) {
+	bumps := []moduleBump{
+		{FileName: "/code/prod/main.tf", Name: "vpc", Repo: "git::https://github.com/org/vpc.git", From: "v1.0.0", To: "v2.0.0", IsMajorUpgrade: true},
+		{FileName: "/code/dev/main.tf", Name: "vpc", Repo: "github.com/org/vpc", From: "v1.0.0", To: "v1.1.0"},
+		{FileName: "/code/prod/eks.tf", Name: "eks", Repo: "github.com/org/eks", From: "v1.0.0", To: "v1.0.1"},
+		{FileName: "/code/main.tf", Name: "s3", Repo: "github.com/org/s3", From: "v1.0.0", To: "v1.0.1"},
+	}
+	groups := groupModuleBumps(bumps, ciGroupByAll, "/code/")
+	require.Len(t, groups, 1)
+	assert.Equal(t, bumpGroup{Bumps: bumps}, groups[0])
+
+	groups = groupModuleBumps(bumps, ciGroupByRepo, "/code/")
+	assert.Equal(t, []bumpGroup{
+		{Key: "github.com/org/eks", Description: "from github.com/org/eks", Bumps: []moduleBump{bumps[2]}},
+		{Key: "github.com/org/s3", Description: "from github.com/org/s3", Bumps: []moduleBump{bumps[3]}},
+		{Key: "github.com/org/vpc", Description: "from github.com/org/vpc", Bumps: []moduleBump{bumps[0], bumps[1]}},
+	}, groups)
+
+	groups = groupModuleBumps(bumps, ciGroupByDirectory, "/code/")
+	assert.Equal(t, []bumpGroup{
+		{Key: ".", Description: "in the root directory", Bumps: []moduleBump{bumps[3]}},
+		{Key: "dev", Description: "in dev", Bumps: []moduleBump{bumps[1]}},
+		{Key: "prod", Description: "in prod", Bumps: []moduleBump{bumps[0], bumps[2]}},
+	}, groups)
+
+	groups = groupModuleBumps(bumps, ciGroupBySeverity, "/code/")
+	assert.Equal(t, []bumpGroup{
+		{Key: "major", Description: "with major upgrades", Bumps: []moduleBump{bumps[0]}},
+		{Key: "minor", Description: "with minor and patch upgrades", Bumps: []moduleBump{bumps[1], bumps[2], bumps[3]}},
+	}, groups)
+}
+
+func TestGetBranchSlug(t *testing.T) {
+	assert.Equal(t, "github.com-org-vpc", getBranchSlug("github.com/org/vpc"))
+	assert.Equal(t, "live-prod-eu-west-1", getBranchSlug("live/prod eu-west-1"))
+	assert.Equal(t, "root", getBranchSlug("."))
+}
+
+func TestUpgradeModuleGroups(t *testing.T) {
+	groupBy := GroupBy
+	t.Cleanup(func() { GroupBy = groupBy })
+	dir, _ := newTestRepoWithRemote(t)
+	upgrade := newTestUpgrade(t, dir)
+	upgrade.startBranch = "master"
+	upgrade.format = func(ctx context.Context, content []byte) ([]byte, error) {
+		return hclwrite.Format(content), nil
+	}
+	// Neither group bumps anything in it
+	unformattedTf := "output \"vpc_id\" {\nvalue=module.vpc.id\n}\n"
+	require.NoError(t, os.WriteFile(dir+"/outputs.tf", []byte(unformattedTf), os.ModePerm))
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+	_, err = worktree.Add("main.tf")
+	require.NoError(t, err)
+	_, err = worktree.Add("outputs.tf")
+	require.NoError(t, err)
+	_, err = worktree.Commit("modules", &git.CommitOptions{Author: &object.Signature{Name: "samwise", When: time.Now()}})
+	require.NoError(t, err)
+
+	GroupBy = ciGroupBySeverity
+	updates, err := updateDirectories(context.Background(), upgrade, nil, false)
+	require.NoError(t, err)
+	moduleBumps, _ := getModuleBumps(updates)
+	groups := groupModuleBumps(moduleBumps, GroupBy, dir)
+	require.Len(t, groups, 2)
+	for _, group := range groups {
+		require.NoError(t, upgradeModuleGroup(context.Background(), upgrade, group, "upgrade/tf-modules-"+group.Key))
+	}
+
+	content, err := os.ReadFile(dir + "/main.tf")
+	require.NoError(t, err)
+	assert.Equal(t, testModulesTf, string(content), "base branch not restored")
+	content, err = os.ReadFile(dir + "/outputs.tf")
+	require.NoError(t, err)
+	assert.Equal(t, unformattedTf, string(content), "file without bumps formatted")
+	for branch, source := range map[string]string{"major": "vpc.git?ref=v2.0.0", "minor": "eks.git?ref=v1.1.0"} {
+		ref, err := repo.Reference(plumbing.NewBranchReferenceName("upgrade/tf-modules-"+branch), true)
+		require.NoError(t, err)
+		commit, err := repo.CommitObject(ref.Hash())
+		require.NoError(t, err)
+		file, err := commit.File("main.tf")
+		require.NoError(t, err)
+		committed, err := file.Contents()
+		require.NoError(t, err)
+		assert.Contains(t, committed, source)
+		assert.Contains(t, committed, "ref=v1.0.0", "bumps of the other group committed to "+branch)
+		stats, err := commit.Stats()
+		require.NoError(t, err)
+		require.Len(t, stats, 1)
+		assert.Equal(t, "main.tf", stats[0].Name, "file without bumps changed on "+branch)
+	}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/schollz/progressbar/v3"
+	"github.com/spf13/viper"
+	"github.com/thundersparkf/samwise/cmd/errorHandlers"
+	"github.com/thundersparkf/samwise/pkg/resolver"
+	"github.com/thundersparkf/samwise/pkg/scanner"
+)
+
+var CacheTTL time.Duration
+var NoCache bool
+
+// Scanner options from the --depth and --ignore flags
+func getScannerOptions(rootDir string, onError func(path string, err error) error) scanner.Options {
+	return scanner.Options{Path: rootDir, Depth: Depth, Ignore: DirectoriesToIgnore, OnError: onError}
+}
+
+// Cache directory from the cache_dir config, defaulting to samwise under the user cache dir
+func getCacheDir() string {
+	if cacheDir := viper.GetString("cache_dir"); cacheDir != "" {
+		return cacheDir
+	}
+	cacheDir, err := resolver.DefaultCacheDir()
+	if CheckNonPanic(err, "options :: getCacheDir :: unable to find the user cache directory, caching is off") {
+		return ""
+	}
+	return cacheDir
+}
+
+// Entry of the tag_rules config, see resolver.TagRule
+type tagRuleConfig struct {
+	Repo               string   `mapstructure:"repo"`
+	Prefix             string   `mapstructure:"prefix"`
+	Pattern            string   `mapstructure:"pattern"`
+	ExcludePrereleases bool     `mapstructure:"exclude_prereleases"`
+	Ignore             []string `mapstructure:"ignore"`
+}
+
+// Tag rules of the tag_rules config in order, failing on regular expressions that don't compile
+func getTagRules() ([]resolver.TagRule, error) {
+	var configs []tagRuleConfig
+	if err := viper.UnmarshalKey("tag_rules", &configs); err != nil {
+		return nil, errors.New(errorHandlers.TagRulesErrorPrefix + err.Error())
+	}
+	tagRules := make([]resolver.TagRule, 0, len(configs))
+	for i, config := range configs {
+		tagRule := resolver.TagRule{Prefix: config.Prefix, ExcludePrereleases: config.ExcludePrereleases, Ignore: config.Ignore}
+		var err error
+		if config.Repo != "" {
+			tagRule.Repo, err = regexp.Compile(config.Repo)
+		}
+		if err == nil && config.Pattern != "" {
+			tagRule.Pattern, err = regexp.Compile(config.Pattern)
+		}
+		if err != nil {
+			return nil, errors.New(errorHandlers.TagRulesErrorPrefix + strconv.Itoa(i) + " :: " + err.Error())
+		}
+		tagRules = append(tagRules, tagRule)
+	}
+	log.Debug().Msgf("options :: getTagRules :: rules :: %d", len(tagRules))
+	return tagRules, nil
+}
+
+// Resolver configured from the flags and config, drawing a progress bar of the lookups
+func newModuleResolver() (*resolver.Resolver, error) {
+	tagRules, err := getTagRules()
+	if err != nil {
+		return nil, err
+	}
+	var bar *progressbar.ProgressBar
+	return resolver.New(resolver.Options{
+		Concurrency:   Concurrency,
+		CacheDir:      getCacheDir(),
+		CacheTTL:      CacheTTL,
+		NoCache:       NoCache,
+		ReadOnlyCache: DryRun,
+		GitUser:       viper.GetString("git_user"),
+		GitKey:        viper.GetString("git_key"),
+		GitSSHKeyPath: viper.GetString("git_ssh_key_path"),
+		RegistryToken: viper.GetString("registry_token"),
+		TagRules:      tagRules,
+		Progress: func(done int, total int) {
+			if bar == nil {
+				bar = progressbar.Default(int64(total))
+			}
+			CheckNonPanic(bar.Set(done), "options :: newModuleResolver :: progressbar error")
+			log.Debug().Msgf("options :: newModuleResolver :: lookups :: %d/%d", done, total)
+		},
+	}), nil
+}
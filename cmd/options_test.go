@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thundersparkf/samwise/cmd/errorHandlers"
+)
+
+func TestGetTagRules(t *testing.T) {
+	t.Cleanup(func() { viper.Set("tag_rules", nil) })
+	tagRules, err := getTagRules()
+	require.NoError(t, err)
+	assert.Empty(t, tagRules)
+
+	viper.Set("tag_rules", []map[string]any{
+		{"repo": `github\.com/org/modules//vpc`, "prefix": "vpc-", "exclude_prereleases": true, "ignore": []string{"vpc-v1.3.0"}},
+		{"pattern": `^modules/(?P<version>.+)$`},
+	})
+	tagRules, err = getTagRules()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(tagRules))
+	assert.True(t, tagRules[0].Repo.MatchString("github.com/org/modules//vpc"))
+	assert.Equal(t, "vpc-", tagRules[0].Prefix)
+	assert.True(t, tagRules[0].ExcludePrereleases)
+	assert.Equal(t, []string{"vpc-v1.3.0"}, tagRules[0].Ignore)
+	assert.Nil(t, tagRules[0].Pattern)
+	assert.Nil(t, tagRules[1].Repo, "rule without a repo not applied to every source")
+	version, ok := tagRules[1].Version("modules/1.2.0")
+	assert.True(t, ok)
+	assert.Equal(t, "1.2.0", version)
+
+	viper.Set("tag_rules", []map[string]any{{"pattern": "(unclosed"}})
+	_, err = getTagRules()
+	assert.ErrorContains(t, err, errorHandlers.TagRulesErrorPrefix+"0")
+	_, err = newModuleResolver()
+	assert.ErrorContains(t, err, errorHandlers.TagRulesErrorPrefix)
+}
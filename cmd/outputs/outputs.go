@@ -2,3 +2,7 @@ package outputs
 
 const JSON = "json"
 const CSV = "csv"
+const MARKDOWN = "markdown"
+const SARIF = "sarif"
+const JUNIT = "junit"
+const HTML = "html"
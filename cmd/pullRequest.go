@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"github.com/thundersparkf/samwise/cmd/errorHandlers"
+	"github.com/thundersparkf/samwise/pkg/forge"
+)
+
+var OpenPullRequest bool
+var ForgeName string
+var ForgeURL string
+var BaseBranch string
+var ForgeRepository string
+
+// Client of the --forge at --forge-url, authenticated with the forge_token config or else git_key
+func newForge() (forge.Forge, error) {
+	token := viper.GetString("forge_token")
+	if token == "" {
+		token = viper.GetString("git_key")
+	}
+	return forge.New(ForgeName, forge.Options{BaseURL: ForgeURL, Token: token, Username: viper.GetString("forge_user")})
+}
+
+// Path of the repository on the forge(org/infra) from the url of the remote, for --forge-repository left out
+func getForgeRepository(repoPath string, remoteName string) (string, error) {
+	repo, err := git.PlainOpenWithOptions(repoPath, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return "", errors.New(errorHandlers.PullRequestErrorPrefix + err.Error())
+	}
+	remote, err := repo.Remote(remoteName)
+	if err != nil {
+		return "", errors.New(errorHandlers.PullRequestErrorPrefix + remoteName + " " + err.Error())
+	}
+	remoteUrl := remote.Config().URLs[0]
+	endpoint, err := transport.NewEndpoint(remoteUrl)
+	if err != nil || endpoint.Protocol == "file" {
+		return "", errors.New(errorHandlers.PullRequestErrorPrefix + "unable to tell the repository from " + remoteUrl + ", set it with --forge-repository")
+	}
+	return strings.TrimSuffix(strings.Trim(endpoint.Path, "/"), ".git"), nil
+}
+
+// Markdown body of the pull request, a table of every module bump after a warning listing the major upgrades
+func getPullRequestBody(bumps []moduleBump) string {
+	var body strings.Builder
+	body.WriteString("Updates the refs of " + strconv.Itoa(len(bumps)) + " terraform module(s) to their latest tag.\n\n")
+	var majorUpgrades []string
+	rows := make([][]string, 0, len(bumps))
+	for _, bump := range bumps {
+		to := markdownCode(bump.To)
+		if tagUrl := forge.TagURL(bump.Repo, bump.To); tagUrl != "" {
+			to = "[" + to + "](" + tagUrl + ")"
+		}
+		upgrade := ""
+		if bump.IsMajorUpgrade {
+			upgrade = "major"
+			majorUpgrades = append(majorUpgrades, "> - `module."+bump.Name+"` "+markdownCode(bump.From)+" -> "+to)
+		}
+		rows = append(rows, []string{markdownCode("module." + bump.Name), escapeMarkdownCell(bump.Repo), markdownCode(bump.From), to, upgrade, escapeMarkdownCell(bump.FileName)})
+	}
+	if len(majorUpgrades) > 0 {
+		body.WriteString("> [!WARNING]\n> " + strconv.Itoa(len(majorUpgrades)) + " major upgrade(s), check the upstream release notes for breaking changes:\n")
+		body.WriteString(strings.Join(majorUpgrades, "\n") + "\n\n")
+	}
+	CheckNonPanic(writeMarkdownTable(&body, []string{"Module", "Source", "From", "To", "Upgrade", "File"}, rows), "pullRequest :: getPullRequestBody :: unable to write table")
+	return strings.TrimSuffix(body.String(), "\n")
+}
+
+// Opens a pull request from the pushed upgrade branch into the base branch, or updates the one already open
+func openUpgradePullRequest(ctx context.Context, pullRequestForge forge.Forge, repository string, branch string, base string, message string, bumps []moduleBump) error {
+	title, _, _ := strings.Cut(message, "\n")
+	result, err := pullRequestForge.OpenPullRequest(ctx, forge.PullRequest{
+		Repository: repository,
+		Head:       branch,
+		Base:       base,
+		Title:      title,
+		Body:       getPullRequestBody(bumps),
+		Labels:     viper.GetStringSlice("pull_request_labels"),
+		Reviewers:  viper.GetStringSlice("pull_request_reviewers"),
+	})
+	if err != nil {
+		return errors.New(errorHandlers.PullRequestErrorPrefix + branch + " -> " + base + " " + err.Error())
+	}
+	if result.Created {
+		log.Info().Msgf("Opened pull request #%d %s", result.Number, result.URL)
+	} else {
+		log.Info().Msgf("Updated pull request #%d %s", result.Number, result.URL)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thundersparkf/samwise/cmd/errorHandlers"
+	"github.com/thundersparkf/samwise/pkg/forge"
+)
+
+// Functions to help testing
+
+// Forge keeping the last pull request it was asked to open
+type testForge struct {
+	pullRequest forge.PullRequest
+	err         error
+}
+
+func (f *testForge) OpenPullRequest(ctx context.Context, pullRequest forge.PullRequest) (forge.PullRequestResult, error) {
+	f.pullRequest = pullRequest
+	return forge.PullRequestResult{Number: 7, URL: "https://github.com/org/infra/pull/7", Created: true}, f.err
+}
+
+func TestGetPullRequestBody(t *testing.T) {
+	body := getPullRequestBody([]moduleBump{
+		{FileName: "infra/main.tf", Name: "vpc", Repo: "github.com/org/vpc", From: "v1.0.0", To: "v2.0.0", IsMajorUpgrade: true},
+		{FileName: "infra/eks.tf", Name: "eks", Repo: "git::https://git.example.com/org/eks.git", From: "v1.0.0", To: "v1.1.0"},
+	})
+	assert.Equal(t, "Updates the refs of 2 terraform module(s) to their latest tag.\n\n"+
+		"> [!WARNING]\n> 1 major upgrade(s), check the upstream release notes for breaking changes:\n"+
+		"> - `module.vpc` `v1.0.0` -> [`v2.0.0`](https://github.com/org/vpc/releases/tag/v2.0.0)\n\n"+
+		"| Module | Source | From | To | Upgrade | File |\n| --- | --- | --- | --- | --- | --- |\n"+
+		"| `module.vpc` | github.com/org/vpc | `v1.0.0` | [`v2.0.0`](https://github.com/org/vpc/releases/tag/v2.0.0) | major | infra/main.tf |\n"+
+		"| `module.eks` | git::https://git.example.com/org/eks.git | `v1.0.0` | `v1.1.0` |  | infra/eks.tf |\n", body)
+	assert.NotContains(t, getPullRequestBody([]moduleBump{{Name: "eks", Repo: "github.com/org/eks", From: "v1.0.0", To: "v1.1.0"}}), "WARNING")
+}
+
+func TestGetForgeRepository(t *testing.T) {
+	dir, _ := newTestRepoWithRemote(t)
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	for name, remoteUrl := range map[string]string{"https": "https://github.com/org/infra.git", "ssh": "git@github.com:org/infra.git"} {
+		_, err = repo.CreateRemote(&config.RemoteConfig{Name: name, URLs: []string{remoteUrl}})
+		require.NoError(t, err)
+		repository, err := getForgeRepository(dir, name)
+		require.NoError(t, err)
+		assert.Equal(t, "org/infra", repository, name)
+	}
+	_, err = getForgeRepository(dir, git.DefaultRemoteName)
+	assert.ErrorContains(t, err, "set it with --forge-repository")
+	_, err = getForgeRepository(dir, "missing")
+	assert.ErrorContains(t, err, errorHandlers.PullRequestErrorPrefix+"missing")
+}
+
+func TestNewForge(t *testing.T) {
+	t.Cleanup(func() { ForgeName, ForgeURL = forge.GitHubForge, "" })
+	ForgeName = forge.GitLabForge
+	pullRequestForge, err := newForge()
+	require.NoError(t, err)
+	assert.IsType(t, &forge.GitLab{}, pullRequestForge)
+	ForgeName = forge.BitbucketServerForge
+	_, err = newForge()
+	assert.ErrorContains(t, err, "needs the url of its REST API")
+	ForgeURL = "https://bitbucket.example.com/rest/api/1.0"
+	_, err = newForge()
+	assert.NoError(t, err)
+	ForgeName = "gitea"
+	_, err = newForge()
+	assert.ErrorContains(t, err, "forge gitea not supported")
+}
+
+func TestOpenUpgradePullRequest(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Set("pull_request_labels", nil)
+		viper.Set("pull_request_reviewers", nil)
+	})
+	viper.Set("pull_request_labels", []string{"dependencies", "terraform"})
+	viper.Set("pull_request_reviewers", []string{"octocat"})
+	bumps := []moduleBump{{FileName: "infra/main.tf", Name: "vpc", Repo: "github.com/org/vpc", From: "v1.0.0", To: "v1.1.0"}}
+	pullRequestForge := &testForge{}
+	err := openUpgradePullRequest(context.Background(), pullRequestForge, "org/infra", "upgrade/tf-modules-2024-11-05", "main", getCommitMessage(bumps, ""), bumps)
+	require.NoError(t, err)
+	assert.Equal(t, forge.PullRequest{
+		Repository: "org/infra",
+		Head:       "upgrade/tf-modules-2024-11-05",
+		Base:       "main",
+		Title:      "Update 1 terraform module(s)",
+		Body:       getPullRequestBody(bumps),
+		Labels:     []string{"dependencies", "terraform"},
+		Reviewers:  []string{"octocat"},
+	}, pullRequestForge.pullRequest)
+
+	pullRequestForge.err = errors.New("401 Unauthorized")
+	err = openUpgradePullRequest(context.Background(), pullRequestForge, "org/infra", "upgrade/tf-modules-2024-11-05", "main", getCommitMessage(bumps, ""), bumps)
+	assert.ErrorContains(t, err, errorHandlers.PullRequestErrorPrefix+"upgrade/tf-modules-2024-11-05 -> main 401 Unauthorized")
+}
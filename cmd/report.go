@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/thundersparkf/samwise/pkg/resolver"
+	"github.com/thundersparkf/samwise/pkg/scanner"
+)
+
+// Version of the json report layout(schemas/report.v1.schema.json). The minor version is bumped when fields are added to
+// ModuleUsage or UpdateResult, the major version(and the schema file) when existing consumers would break.
+const ReportSchemaVersion = "1.4.0"
+
+// Appended to the repo in the csv report for modules with a major update when --major is set
+const majorUpgradeSuffix = "[MAJOR UPGRADE AVAILABLE]"
+
+// ModuleUsage is a module block found in a terraform file, see scanner.ModuleUsage
+type ModuleUsage = scanner.ModuleUsage
+
+// UpdateResult is the outcome of checking a module block for newer versions of its source, see resolver.UpdateResult
+type UpdateResult = resolver.UpdateResult
+
+// Report is the layout of the json module and failure reports
+type Report struct {
+	SchemaVersion string         `json:"schema_version"`
+	Report        []UpdateResult `json:"report"`
+}
+
+// Updates joined by | as the csv report has always listed them
+func joinedUpdates(result UpdateResult) string {
+	return strings.Join(result.UpdatesAvailable, "|")
+}
+
+func constraintExcludesLatestString(result UpdateResult) string {
+	if result.ConstraintExcludesLatest == nil {
+		return ""
+	}
+	return strconv.FormatBool(*result.ConstraintExcludesLatest)
+}
+
+// Module blocks pinned to a commit, a branch, nothing or a ref that couldn't be told apart
+func getUnpinnedModules(data []UpdateResult) []UpdateResult {
+	var unpinned []UpdateResult
+	for _, module := range data {
+		if module.IsUnpinned() {
+			unpinned = append(unpinned, module)
+		}
+	}
+	return unpinned
+}
+
+// Describes what an unpinned module block is pinned to, e.g. branch main, along with where the ref stands in the
+// history of its source when it was looked up
+func getPinningDescription(module UpdateResult) string {
+	switch module.Pinning {
+	case scanner.PinningNone:
+		return "no ref or version"
+	case scanner.PinningUnknown:
+		return "unknown ref " + module.CurrentVersion
+	}
+	description := module.Pinning + " " + module.CurrentVersion
+	if refDescription := getRefDescription(module); refDescription != "" {
+		description += ", " + refDescription
+	}
+	return description
+}
+
+// Describes where the commit or branch of a module block stands against the default branch of its source, e.g.
+// 3 commit(s) and 1 tag(s) behind main, released in v1.2.0. Empty when the ref wasn't looked up.
+func getRefDescription(module UpdateResult) string {
+	if module.Ref == nil {
+		return ""
+	}
+	description := strconv.Itoa(module.Ref.CommitsBehind) + " commit(s) and " + strconv.Itoa(module.Ref.TagsBehind) + " tag(s) behind " + module.Ref.DefaultBranch
+	if module.Pinning == scanner.PinningBranch {
+		description = "at " + shortCommit(module.Ref.Commit) + ", " + description
+		if module.Ref.NearestTag != "" {
+			description += ", newest tag " + module.Ref.NearestTag
+		}
+	}
+	if module.Ref.ContainingTag == "" {
+		return description + ", not released yet"
+	}
+	return description + ", released in " + module.Ref.ContainingTag
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+// Source of a failure as written in the module block, the parsed source when it isn't known
+func failureSource(failure UpdateResult) string {
+	if failure.Source != "" {
+		return failure.Source
+	}
+	return failure.SourceWithSubmodule()
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thundersparkf/samwise/pkg/resolver"
+	"github.com/thundersparkf/samwise/pkg/scanner"
+)
+
+// Json field names of the struct, embedded structs included
+func getJSONFieldNames(structType reflect.Type) []string {
+	var names []string
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+		if field.Anonymous {
+			names = append(names, getJSONFieldNames(field.Type)...)
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestReportSchemaMatchesUpdateResult(t *testing.T) {
+	content, err := os.ReadFile("../schemas/report.v1.schema.json")
+	assert.Empty(t, err)
+	var schema struct {
+		Properties struct {
+			SchemaVersion struct {
+				Pattern string `json:"pattern"`
+			} `json:"schema_version"`
+		} `json:"properties"`
+		Defs struct {
+			UpdateResult struct {
+				Properties map[string]any `json:"properties"`
+			} `json:"updateResult"`
+			RefInfo struct {
+				Properties map[string]any `json:"properties"`
+			} `json:"refInfo"`
+		} `json:"$defs"`
+	}
+	err = json.Unmarshal(content, &schema)
+	assert.Empty(t, err)
+
+	var schemaFields []string
+	for name := range schema.Defs.UpdateResult.Properties {
+		schemaFields = append(schemaFields, name)
+	}
+	structFields := getJSONFieldNames(reflect.TypeOf(UpdateResult{}))
+	sort.Strings(schemaFields)
+	sort.Strings(structFields)
+	assert.Equal(t, structFields, schemaFields, "schema out of sync with UpdateResult")
+
+	var refSchemaFields []string
+	for name := range schema.Defs.RefInfo.Properties {
+		refSchemaFields = append(refSchemaFields, name)
+	}
+	refFields := getJSONFieldNames(reflect.TypeOf(resolver.RefInfo{}))
+	sort.Strings(refSchemaFields)
+	sort.Strings(refFields)
+	assert.Equal(t, refFields, refSchemaFields, "schema out of sync with RefInfo")
+	assert.True(t, strings.HasPrefix(schema.Properties.SchemaVersion.Pattern, "^"+strings.Split(ReportSchemaVersion, ".")[0]+"\\."), "schema major version differs from ReportSchemaVersion")
+}
+
+func TestUpdateResult(t *testing.T) {
+	result := newUpdateResult("github.com/test_repo", "1.0.0", "main.tf", "1.1.0", "1.2.0")
+	assert.True(t, result.HasUpdates())
+	assert.Equal(t, "1.1.0|1.2.0", joinedUpdates(result))
+	assert.Equal(t, "", constraintExcludesLatestString(result))
+	excluded := false
+	result.ConstraintExcludesLatest = &excluded
+	assert.Equal(t, "false", constraintExcludesLatestString(result))
+	assert.False(t, newUpdateResult("github.com/test_repo", "1.0.0", "main.tf").HasUpdates())
+}
+
+func TestGetPinningDescription(t *testing.T) {
+	module := UpdateResult{ModuleUsage: ModuleUsage{CurrentVersion: "a1b2c3d", Pinning: scanner.PinningCommit}}
+	assert.Equal(t, "commit a1b2c3d", getPinningDescription(module))
+	module.Ref = &resolver.RefInfo{Commit: "a1b2c3d4e5f6", DefaultBranch: "main", ContainingTag: "v1.2.0", NearestTag: "v1.1.0", CommitsBehind: 4, TagsBehind: 1}
+	assert.Equal(t, "commit a1b2c3d, 4 commit(s) and 1 tag(s) behind main, released in v1.2.0", getPinningDescription(module))
+
+	module.CurrentVersion, module.Pinning = "develop", scanner.PinningBranch
+	assert.Equal(t, "branch develop, at a1b2c3d, 4 commit(s) and 1 tag(s) behind main, newest tag v1.1.0, released in v1.2.0", getPinningDescription(module))
+	module.Ref.ContainingTag = ""
+	assert.Equal(t, "at a1b2c3d, 4 commit(s) and 1 tag(s) behind main, newest tag v1.1.0, not released yet", getRefDescription(module))
+
+	assert.Equal(t, "no ref or version", getPinningDescription(UpdateResult{ModuleUsage: ModuleUsage{Pinning: scanner.PinningNone}}))
+	assert.Equal(t, "", getRefDescription(UpdateResult{}))
+}
@@ -17,6 +17,8 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"github.com/sirupsen/logrus"
 	"os"
 
@@ -25,6 +27,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/thundersparkf/samwise/cmd/errorHandlers"
 )
 
 var cfgFile string
@@ -39,11 +42,14 @@ var rootCmd = &cobra.Command{
 		and provide a report to plan updates and migrations.
 
 	The Samwise Gamgee of module management to the Frodo of your application.`,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		if err := setUpLogs(v); err != nil {
-			panic(err)
+			return errorHandlers.UsageError(err)
 		}
+		return nil
 	},
+	// Errors are printed by Execute, which also picks the exit code
+	SilenceErrors: true,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -55,10 +61,23 @@ func Execute() {
 
 	err = rootCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		os.Exit(handleExecuteError(err))
 	}
 }
 
+// Prints the error a command failed with and returns the exit code for it
+func handleExecuteError(err error) int {
+	exitCode := errorHandlers.ExitCodeError
+	var exitError *errorHandlers.ExitError
+	if errors.As(err, &exitError) {
+		exitCode = exitError.Code
+	}
+	if message := err.Error(); message != "" {
+		_, _ = fmt.Fprintln(rootCmd.ErrOrStderr(), "Error: "+message)
+	}
+	return exitCode
+}
+
 func init() {
 	cobra.OnInitialize(initConfig)
 
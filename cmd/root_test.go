@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thundersparkf/samwise/cmd/errorHandlers"
+)
+
+func TestHandleExecuteError(t *testing.T) {
+	var stderr bytes.Buffer
+	rootCmd.SetErr(&stderr)
+	t.Cleanup(func() { rootCmd.SetErr(nil) })
+
+	assert.Equal(t, errorHandlers.ExitCodeError, handleExecuteError(errors.New("unable to scan ./missing")))
+	assert.Equal(t, "Error: unable to scan ./missing\n", stderr.String())
+
+	stderr.Reset()
+	assert.Equal(t, errorHandlers.ExitCodeUsage, handleExecuteError(errorHandlers.UsageError(errors.New(errorHandlers.FailOnError))))
+	assert.Equal(t, "Error: "+errorHandlers.FailOnError+"\n", stderr.String())
+
+	stderr.Reset()
+	assert.Equal(t, errorHandlers.ExitCodeMajorUpdate, handleExecuteError(&errorHandlers.ExitError{Code: errorHandlers.ExitCodeMajorUpdate}))
+	assert.Empty(t, stderr.String(), "message printed for a fail-on exit")
+}
@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/thundersparkf/samwise/pkg/resolver"
+)
+
+const sarifExtension = "sarif"
+const sarifVersion = "2.1.0"
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// Base of the artifact uris, the --path scanned, which code scanning uploads resolve to the repository root
+const sarifSourceRoot = "%SRCROOT%"
+
+const sarifRuleOutdated = "outdated-module"
+const sarifRuleMajorBehind = "major-version-behind"
+const sarifRuleUnresolvable = "unresolvable-module-source"
+const sarifRuleUnpinned = "unpinned-module-source"
+
+// SARIF result levels by upgrade severity
+var sarifLevels = map[string]string{
+	resolver.SeverityMajor: "error",
+	resolver.SeverityMinor: "warning",
+	resolver.SeverityPatch: "note",
+}
+
+var sarifRules = []sarifRule{
+	{
+		Id:                   sarifRuleOutdated,
+		Name:                 "OutdatedModule",
+		ShortDescription:     sarifMessage{Text: "Module source has newer versions available"},
+		DefaultConfiguration: sarifConfiguration{Level: "warning"},
+	},
+	{
+		Id:                   sarifRuleMajorBehind,
+		Name:                 "MajorVersionBehind",
+		ShortDescription:     sarifMessage{Text: "Module source is at least one major version behind"},
+		DefaultConfiguration: sarifConfiguration{Level: "error"},
+	},
+	{
+		Id:                   sarifRuleUnresolvable,
+		Name:                 "UnresolvableModuleSource",
+		ShortDescription:     sarifMessage{Text: "Versions of the module source could not be looked up"},
+		DefaultConfiguration: sarifConfiguration{Level: "warning"},
+	},
+	{
+		Id:                   sarifRuleUnpinned,
+		Name:                 "UnpinnedModuleSource",
+		ShortDescription:     sarifMessage{Text: "Module block is pinned to a commit, a branch or nothing rather than a version"},
+		DefaultConfiguration: sarifConfiguration{Level: "warning"},
+	},
+}
+
+type sarifReport struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationUri string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	Id                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleId     string            `json:"ruleId"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	Uri       string `json:"uri"`
+	UriBaseId string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+func createSARIFReportFile(data []UpdateResult, failureList []UpdateResult, path string, filename string) error {
+	report, reportFilePath, err := createReportWriter(path, filename, sarifExtension)
+	if err != nil {
+		return err
+	}
+	err = writeSARIFReport(data, failureList, fixTrailingSlashForPath(Path), report, reportFilePath)
+	return closeReportWriter(report, reportFilePath, err)
+}
+
+// Location of the source attribute of the module block relative to rootDir, the block itself is the logical location
+func getSARIFLocations(module ModuleUsage, rootDir string) []sarifLocation {
+	if module.FileName == "" {
+		return nil
+	}
+	location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{Uri: getDisplayPath(rootDir, module.FileName), UriBaseId: sarifSourceRoot},
+	}}
+	if module.Line > 0 {
+		location.PhysicalLocation.Region = &sarifRegion{StartLine: module.Line, StartColumn: module.Column, EndLine: module.EndLine, EndColumn: module.EndColumn}
+	}
+	if module.Name != "" {
+		location.LogicalLocations = []sarifLogicalLocation{{Name: module.Name, FullyQualifiedName: module.Address(), Kind: "module"}}
+	}
+	return []sarifLocation{location}
+}
+
+// module.<name>(<source>) the messages refer to the block by, just the source when the name is unknown
+func getSARIFSubject(module ModuleUsage) string {
+	if module.Name == "" {
+		return module.SourceWithSubmodule()
+	}
+	return module.Address() + "(" + module.SourceWithSubmodule() + ")"
+}
+
+// Properties every result of the module block carries
+func getSARIFProperties(module ModuleUsage) map[string]string {
+	properties := map[string]string{"repo": module.Repo, "current_version": module.CurrentVersion}
+	if module.Name != "" {
+		properties["module_name"] = module.Name
+	}
+	if module.Submodule != "" {
+		properties["submodule"] = module.Submodule
+	}
+	return properties
+}
+
+// Returns one result per outdated or unpinned module block and per failure
+func getSARIFResults(data []UpdateResult, failureList []UpdateResult, rootDir string) []sarifResult {
+	results := make([]sarifResult, 0)
+	for _, module := range data {
+		if module.CurrentVersion == "" || !module.HasUpdates() {
+			continue
+		}
+		ruleId := sarifRuleOutdated
+		if module.IsMajorUpgrade {
+			ruleId = sarifRuleMajorBehind
+		}
+		level, ok := sarifLevels[module.UpgradeSeverity]
+		if !ok {
+			level = "warning"
+		}
+		message := getSARIFSubject(module.ModuleUsage) + " is at " + module.CurrentVersion + ", " + module.LatestVersion + " is available(newer versions: " + strings.Join(module.UpdatesAvailable, ", ") + ")"
+		properties := getSARIFProperties(module.ModuleUsage)
+		properties["latest_version"] = module.LatestVersion
+		properties["upgrade_severity"] = module.UpgradeSeverity
+		results = append(results, sarifResult{
+			RuleId:     ruleId,
+			Level:      level,
+			Message:    sarifMessage{Text: message},
+			Locations:  getSARIFLocations(module.ModuleUsage, rootDir),
+			Properties: properties,
+		})
+	}
+	for _, module := range getUnpinnedModules(data) {
+		message := getSARIFSubject(module.ModuleUsage) + " is not pinned to a version(" + getPinningDescription(module) + ")"
+		if module.LatestVersion != "" {
+			message += ", " + module.LatestVersion + " is available"
+		}
+		properties := getSARIFProperties(module.ModuleUsage)
+		properties["pinning"] = module.Pinning
+		properties["latest_version"] = module.LatestVersion
+		results = append(results, sarifResult{
+			RuleId:     sarifRuleUnpinned,
+			Level:      "warning",
+			Message:    sarifMessage{Text: message},
+			Locations:  getSARIFLocations(module.ModuleUsage, rootDir),
+			Properties: properties,
+		})
+	}
+	for _, failure := range failureList {
+		subject := getSARIFSubject(failure.ModuleUsage)
+		if subject == "" {
+			// Files that could not be scanned
+			subject = failure.FileName
+		}
+		message := subject + " could not be looked up: " + failure.Error
+		if failure.ErrorCode != "" {
+			message = subject + " failed at the " + string(failure.ErrorStage) + " stage(" + string(failure.ErrorCode) + "): " + failure.Error
+		}
+		properties := getSARIFProperties(failure.ModuleUsage)
+		properties["source"] = failure.Source
+		properties["error_stage"] = string(failure.ErrorStage)
+		properties["error_code"] = string(failure.ErrorCode)
+		results = append(results, sarifResult{
+			RuleId:     sarifRuleUnresolvable,
+			Level:      "warning",
+			Message:    sarifMessage{Text: message},
+			Locations:  getSARIFLocations(failure.ModuleUsage, rootDir),
+			Properties: properties,
+		})
+	}
+	return results
+}
+
+func writeSARIFReport(data []UpdateResult, failureList []UpdateResult, rootDir string, report io.Writer, reportFilePath string) error {
+	sarif := sarifReport{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "samwise",
+				InformationUri: "https://github.com/thundersparkf/samwise",
+				Rules:          sarifRules,
+			}},
+			Results: getSARIFResults(data, failureList, rootDir),
+		}},
+	}
+	reportString, err := json.MarshalIndent(sarif, "", "  ")
+	if err == nil {
+		_, err = report.Write(reportString)
+	}
+	if err != nil {
+		return newReportError(reportFilePath, err)
+	}
+	log.Debug().Msgf("created " + reportFilePath)
+	return nil
+}
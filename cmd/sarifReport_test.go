@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thundersparkf/samwise/pkg/resolver"
+	"github.com/thundersparkf/samwise/pkg/scanner"
+)
+
+func TestWriteSARIFReport(t *testing.T) {
+	vpc := newUpdateResult("github.com/org/vpc", "v1.2.0", "./infra/main.tf", "v1.3.0", "v2.0.0")
+	vpc.Name, vpc.Submodule = "vpc", "modules/subnets"
+	vpc.Line, vpc.Column, vpc.EndLine, vpc.EndColumn = 3, 5, 3, 40
+	vpc.UpgradeSeverity, vpc.IsMajorUpgrade = resolver.SeverityMajor, true
+	eks := newUpdateResult("terraform-aws-modules/eks/aws", "4.2.0", "eks/main.tf", "4.2.1")
+	eks.Line, eks.Column = 2, 3
+	eks.UpgradeSeverity = resolver.SeverityPatch
+	unpinned := UpdateResult{ModuleUsage: ModuleUsage{Name: "lambda", Repo: "github.com/org/lambda", CurrentVersion: "a1b2c3d", Pinning: scanner.PinningCommit, FileName: "main.tf", Line: 9}, LatestVersion: "v3.0.0"}
+	data := []UpdateResult{vpc, eks, newUpdateResult("github.com/org/s3", "v1.0.0", "main.tf"), unpinned}
+	failureList := []UpdateResult{
+		{ModuleUsage: ModuleUsage{Repo: "github.com/org/missing", CurrentVersion: "v0.1.0", FileName: "main.tf"}, Error: "not found"},
+	}
+	var report bytes.Buffer
+	require.NoError(t, writeSARIFReport(data, failureList, ".", &report, "buffer"))
+	var sarif sarifReport
+	err := json.Unmarshal(report.Bytes(), &sarif)
+	assert.Empty(t, err)
+	assert.Equal(t, sarifVersion, sarif.Version)
+	assert.Equal(t, 1, len(sarif.Runs))
+	assert.Equal(t, 4, len(sarif.Runs[0].Tool.Driver.Rules))
+
+	results := sarif.Runs[0].Results
+	assert.Equal(t, 4, len(results))
+	assert.Equal(t, sarifRuleMajorBehind, results[0].RuleId)
+	assert.Equal(t, "error", results[0].Level)
+	assert.Equal(t, "module.vpc(github.com/org/vpc//modules/subnets) is at v1.2.0, v2.0.0 is available(newer versions: v1.3.0, v2.0.0)", results[0].Message.Text)
+	assert.Equal(t, sarifArtifactLocation{Uri: "infra/main.tf", UriBaseId: sarifSourceRoot}, results[0].Locations[0].PhysicalLocation.ArtifactLocation)
+	assert.Equal(t, &sarifRegion{StartLine: 3, StartColumn: 5, EndLine: 3, EndColumn: 40}, results[0].Locations[0].PhysicalLocation.Region)
+	assert.Equal(t, []sarifLogicalLocation{{Name: "vpc", FullyQualifiedName: "module.vpc", Kind: "module"}}, results[0].Locations[0].LogicalLocations)
+	assert.Equal(t, "vpc", results[0].Properties["module_name"])
+	assert.Equal(t, "modules/subnets", results[0].Properties["submodule"])
+
+	assert.Equal(t, sarifRuleOutdated, results[1].RuleId)
+	assert.Equal(t, "note", results[1].Level)
+	assert.Equal(t, "4.2.1", results[1].Properties["latest_version"])
+
+	assert.Equal(t, sarifRuleUnpinned, results[2].RuleId)
+	assert.Equal(t, "module.lambda(github.com/org/lambda) is not pinned to a version(commit a1b2c3d), v3.0.0 is available", results[2].Message.Text)
+	assert.Equal(t, "commit", results[2].Properties["pinning"])
+
+	assert.Equal(t, sarifRuleUnresolvable, results[3].RuleId)
+	assert.Equal(t, "github.com/org/missing could not be looked up: not found", results[3].Message.Text)
+	assert.Nil(t, results[3].Locations[0].PhysicalLocation.Region, "region set without a position")
+	assert.Nil(t, results[3].Locations[0].LogicalLocations, "logical location set without a block name")
+}
+
+func TestGetSARIFLocationsRelativeToPath(t *testing.T) {
+	rootDir := filepath.Join(t.TempDir(), "infra")
+	tests := []struct {
+		rootDir  string
+		fileName string
+		expected string
+	}{
+		{rootDir: rootDir, fileName: filepath.Join(rootDir, "network", "main.tf"), expected: "network/main.tf"},
+		{rootDir: "../infra", fileName: "../infra/main.tf", expected: "main.tf"},
+		{rootDir: ".", fileName: "./eks/main.tf", expected: "eks/main.tf"},
+	}
+	for _, test := range tests {
+		locations := getSARIFLocations(ModuleUsage{FileName: test.fileName}, test.rootDir)
+		assert.Equal(t, sarifArtifactLocation{Uri: test.expected, UriBaseId: sarifSourceRoot}, locations[0].PhysicalLocation.ArtifactLocation, test.fileName)
+	}
+}
+
+func TestGenerateSARIFReport(t *testing.T) {
+	outputDir := t.TempDir()
+	require.NoError(t, generateReport(nil, nil, "module_report", "sarif", outputDir))
+	content, err := os.ReadFile(outputDir + "/module_report.sarif")
+	assert.Empty(t, err)
+	var sarif sarifReport
+	err = json.Unmarshal(content, &sarif)
+	assert.Empty(t, err)
+	assert.NotNil(t, sarif.Runs[0].Results, "results must be an empty array rather than null")
+	assert.Empty(t, sarif.Runs[0].Results)
+}
@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"github.com/thundersparkf/samwise/cmd/errorHandlers"
+	"github.com/thundersparkf/samwise/pkg/resolver"
+)
+
+const defaultBranchTemplate = "upgrade/tf-modules-{{.Date}}"
+const defaultCommitAuthor = "samwise"
+
+var BranchTemplate string
+var Push bool
+var Remote string
+
+// Fields of the run --branch-template can use
+type branchTemplateData struct {
+	// 2006-01-02
+	Date string
+	// 150405, for more than one upgrade branch a day
+	Time string
+	// Group of the branch with --group-by other than all, e.g. github.com-org-vpc
+	Group string
+}
+
+// Name of the upgrade branch from the --branch-template, ending with -<group> unless the template places it
+func getUpgradeBranchName(branchTemplate string, now time.Time, group string) (string, error) {
+	if group != "" && !strings.Contains(branchTemplate, ".Group") {
+		branchTemplate += "-{{.Group}}"
+	}
+	branchNameTemplate, err := template.New("branch").Parse(branchTemplate)
+	if err != nil {
+		return "", errors.New(errorHandlers.BranchTemplateErrorPrefix + err.Error())
+	}
+	var branch strings.Builder
+	err = branchNameTemplate.Execute(&branch, branchTemplateData{Date: now.Format(time.DateOnly), Time: now.Format("150405"), Group: group})
+	if err != nil {
+		return "", errors.New(errorHandlers.BranchTemplateErrorPrefix + err.Error())
+	}
+	if err = plumbing.NewBranchReferenceName(branch.String()).Validate(); err != nil || branch.Len() == 0 {
+		return "", errors.New(errorHandlers.BranchTemplateErrorPrefix + branchTemplate + " :: " + strconv.Quote(branch.String()) + " is not a valid branch name")
+	}
+	return branch.String(), nil
+}
+
+// Commit message listing every module block ci updated, major upgrades marked
+func getCommitMessage(bumps []moduleBump, description string) string {
+	summary := "Update " + strconv.Itoa(len(bumps)) + " terraform module(s)"
+	if description != "" {
+		summary += " " + description
+	}
+	lines := []string{summary, ""}
+	for _, bump := range bumps {
+		line := "- module." + bump.Name + "(" + bump.Repo + ") " + bump.From + " -> " + bump.To + " in " + bump.FileName
+		if bump.IsMajorUpgrade {
+			line += " [major]"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// Author of the upgrade commits from the git_author_name and git_author_email config
+func getCommitAuthor() *object.Signature {
+	name := viper.GetString("git_author_name")
+	if name == "" {
+		name = defaultCommitAuthor
+	}
+	return &object.Signature{Name: name, Email: viper.GetString("git_author_email"), When: time.Now()}
+}
+
+// Paths of the files in the worktree, relative to its root with forward slashes, failing for files outside of it
+func getWorktreePaths(worktree *git.Worktree, files []string) ([]string, error) {
+	root, err := filepath.Abs(worktree.Filesystem.Root())
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		absolutePath, err := filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+		relativePath, err := filepath.Rel(root, absolutePath)
+		if err != nil || strings.HasPrefix(relativePath, "..") {
+			return nil, errors.New(file + " is outside of the repository " + root)
+		}
+		paths = append(paths, filepath.ToSlash(relativePath))
+	}
+	return paths, nil
+}
+
+// Branch checked out in the repository containing repoPath, upgrade branches are created from it and merged back into it
+func getCurrentBranch(repoPath string) (string, error) {
+	repo, err := git.PlainOpenWithOptions(repoPath, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return "", errors.New(errorHandlers.CommitErrorPrefix + err.Error())
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", errors.New(errorHandlers.CommitErrorPrefix + err.Error())
+	}
+	if !head.Name().IsBranch() {
+		return "", errors.New(errorHandlers.CommitErrorPrefix + "HEAD is detached, check out the branch to upgrade")
+	}
+	return head.Name().Short(), nil
+}
+
+// Commits only the updated files to the upgrade branch, failing rather than committing changes staged before the run
+func commitModuleUpdates(repoPath string, branch string, files []string, message string) (plumbing.Hash, error) {
+	repo, err := git.PlainOpenWithOptions(repoPath, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return plumbing.ZeroHash, errors.New(errorHandlers.CommitErrorPrefix + err.Error())
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return plumbing.ZeroHash, errors.New(errorHandlers.CommitErrorPrefix + err.Error())
+	}
+	paths, err := getWorktreePaths(worktree, files)
+	if err != nil {
+		return plumbing.ZeroHash, errors.New(errorHandlers.CommitErrorPrefix + err.Error())
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return plumbing.ZeroHash, errors.New(errorHandlers.CommitErrorPrefix + err.Error())
+	}
+	for path, fileStatus := range status {
+		if fileStatus.Staging != git.Unmodified && fileStatus.Staging != git.Untracked && !slices.Contains(paths, path) {
+			return plumbing.ZeroHash, errors.New(errorHandlers.CommitErrorPrefix + path + " was staged before the run, commit or unstage it first")
+		}
+	}
+
+	branchRef := plumbing.NewBranchReferenceName(branch)
+	_, err = repo.Reference(branchRef, false)
+	create := errors.Is(err, plumbing.ErrReferenceNotFound)
+	log.Debug().Msgf("upgradeBranch :: commitModuleUpdates :: branch :: %s :: create :: %t", branch, create)
+	err = worktree.Checkout(&git.CheckoutOptions{Branch: branchRef, Create: create, Keep: true})
+	if err != nil {
+		return plumbing.ZeroHash, errors.New(errorHandlers.CommitErrorPrefix + "unable to check out " + branch + " " + err.Error())
+	}
+	for _, path := range paths {
+		if _, err = worktree.Add(path); err != nil {
+			return plumbing.ZeroHash, errors.New(errorHandlers.CommitErrorPrefix + err.Error())
+		}
+	}
+	commit, err := worktree.Commit(message, &git.CommitOptions{Author: getCommitAuthor()})
+	if err != nil {
+		return plumbing.ZeroHash, errors.New(errorHandlers.CommitErrorPrefix + err.Error())
+	}
+	log.Debug().Msgf("upgradeBranch :: commitModuleUpdates :: commit :: %s :: files :: %s", commit.String(), strings.Join(paths, " "))
+	return commit, nil
+}
+
+// Checks the base branch out again, putting the committed files back to the contents they had before the run
+func checkoutBaseBranch(repoPath string, base string, originals map[string][]byte) error {
+	repo, err := git.PlainOpenWithOptions(repoPath, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return errors.New(errorHandlers.CommitErrorPrefix + err.Error())
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return errors.New(errorHandlers.CommitErrorPrefix + err.Error())
+	}
+	// Keeping the index and worktree leaves the files as committed to the upgrade branch until they are put back
+	err = worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(base), Keep: true})
+	if err != nil {
+		return errors.New(errorHandlers.CommitErrorPrefix + "unable to check out " + base + " " + err.Error())
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return errors.New(errorHandlers.CommitErrorPrefix + err.Error())
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return errors.New(errorHandlers.CommitErrorPrefix + err.Error())
+	}
+	index, err := repo.Storer.Index()
+	if err != nil {
+		return errors.New(errorHandlers.CommitErrorPrefix + err.Error())
+	}
+	for file, content := range originals {
+		paths, err := getWorktreePaths(worktree, []string{file})
+		if err != nil {
+			return errors.New(errorHandlers.CommitErrorPrefix + err.Error())
+		}
+		// The index entry goes back to the one of the base branch, or away for files it doesn't have
+		_, _ = index.Remove(paths[0])
+		if baseFile, err := commit.File(paths[0]); err == nil {
+			entry := index.Add(paths[0])
+			entry.Hash, entry.Mode, entry.Size = baseFile.Hash, baseFile.Mode, uint32(baseFile.Size)
+		}
+		if err = os.WriteFile(file, content, os.ModePerm); err != nil {
+			return errors.New(errorHandlers.CommitErrorPrefix + "unable to restore " + file + " " + err.Error())
+		}
+	}
+	if err = repo.Storer.SetIndex(index); err != nil {
+		return errors.New(errorHandlers.CommitErrorPrefix + err.Error())
+	}
+	log.Debug().Msgf("upgradeBranch :: checkoutBaseBranch :: base :: %s :: restored :: %d", base, len(originals))
+	return nil
+}
+
+// Pushes the upgrade branch to the remote with the auth module sources are looked up with
+func pushUpgradeBranch(ctx context.Context, moduleResolver *resolver.Resolver, repoPath string, remoteName string, branch string) error {
+	repo, err := git.PlainOpenWithOptions(repoPath, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return errors.New(errorHandlers.PushErrorPrefix + branch + " " + err.Error())
+	}
+	remote, err := repo.Remote(remoteName)
+	if err != nil {
+		return errors.New(errorHandlers.PushErrorPrefix + branch + " to " + remoteName + " " + err.Error())
+	}
+	auth, err := moduleResolver.GitAuth(remote.Config().URLs[0])
+	if err != nil {
+		return errors.New(errorHandlers.PushErrorPrefix + branch + " to " + remoteName + " " + err.Error())
+	}
+	branchRef := plumbing.NewBranchReferenceName(branch)
+	err = repo.PushContext(ctx, &git.PushOptions{
+		RemoteName: remoteName,
+		RefSpecs:   []config.RefSpec{config.RefSpec(branchRef + ":" + branchRef)},
+		Auth:       auth,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return errors.New(errorHandlers.PushErrorPrefix + branch + " to " + remoteName + " " + err.Error())
+	}
+	log.Debug().Msgf("upgradeBranch :: pushUpgradeBranch :: pushed :: %s :: remote :: %s", branch, remoteName)
+	return nil
+}
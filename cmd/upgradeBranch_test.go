@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thundersparkf/samwise/cmd/errorHandlers"
+	"github.com/thundersparkf/samwise/pkg/resolver"
+)
+
+// Creates a repository with infra/main.tf and notes.txt committed and a bare repository as its origin, returning the
+// path of both
+func newTestRepoWithRemote(t *testing.T) (string, string) {
+	dir, remoteDir := t.TempDir(), t.TempDir()
+	_, err := git.PlainInit(remoteDir, true)
+	require.NoError(t, err)
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{remoteDir}})
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(dir+"/infra", os.ModePerm))
+	require.NoError(t, os.WriteFile(dir+"/infra/main.tf", []byte("# v1.0.0\n"), os.ModePerm))
+	require.NoError(t, os.WriteFile(dir+"/notes.txt", []byte("notes\n"), os.ModePerm))
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+	_, err = worktree.Add(".")
+	require.NoError(t, err)
+	_, err = worktree.Commit("initial", &git.CommitOptions{Author: &object.Signature{Name: "samwise", When: time.Now()}})
+	require.NoError(t, err)
+	return dir, remoteDir
+}
+
+func TestGetUpgradeBranchName(t *testing.T) {
+	now := time.Date(2024, 11, 5, 9, 30, 15, 0, time.UTC)
+	branch, err := getUpgradeBranchName(defaultBranchTemplate, now, "")
+	require.NoError(t, err)
+	assert.Equal(t, "upgrade/tf-modules-2024-11-05", branch)
+	branch, err = getUpgradeBranchName("deps/terraform-{{.Date}}-{{.Time}}", now, "")
+	require.NoError(t, err)
+	assert.Equal(t, "deps/terraform-2024-11-05-093015", branch)
+	branch, err = getUpgradeBranchName(defaultBranchTemplate, now, "github.com-org-vpc")
+	require.NoError(t, err)
+	assert.Equal(t, "upgrade/tf-modules-2024-11-05-github.com-org-vpc", branch)
+	branch, err = getUpgradeBranchName("deps/{{.Group}}/{{.Date}}", now, "major")
+	require.NoError(t, err)
+	assert.Equal(t, "deps/major/2024-11-05", branch)
+
+	for _, branchTemplate := range []string{"{{.Commit}}", "{{.Date", "upgrade..{{.Date}}", "", "upgrade/ {{.Date}}"} {
+		_, err = getUpgradeBranchName(branchTemplate, now, "")
+		assert.ErrorContains(t, err, errorHandlers.BranchTemplateErrorPrefix, branchTemplate+" taken for a branch name")
+	}
+}
+
+func TestGetCommitMessage(t *testing.T) {
+	message := getCommitMessage([]moduleBump{
+		{FileName: "infra/main.tf", Name: "vpc", Repo: "github.com/org/vpc", From: "v1.0.0", To: "v2.0.0", IsMajorUpgrade: true},
+		{FileName: "infra/eks.tf", Name: "eks", Repo: "github.com/org/eks", From: "v1.0.0", To: "v1.1.0"},
+	}, "")
+	assert.Equal(t, "Update 2 terraform module(s)\n\n"+
+		"- module.vpc(github.com/org/vpc) v1.0.0 -> v2.0.0 in infra/main.tf [major]\n"+
+		"- module.eks(github.com/org/eks) v1.0.0 -> v1.1.0 in infra/eks.tf\n", message)
+	message = getCommitMessage([]moduleBump{{FileName: "infra/eks.tf", Name: "eks", Repo: "github.com/org/eks", From: "v1.0.0", To: "v1.1.0"}}, "from github.com/org/eks")
+	assert.Equal(t, "Update 1 terraform module(s) from github.com/org/eks\n\n- module.eks(github.com/org/eks) v1.0.0 -> v1.1.0 in infra/eks.tf\n", message)
+}
+
+func TestGetCurrentBranch(t *testing.T) {
+	dir, _ := newTestRepoWithRemote(t)
+	branch, err := getCurrentBranch(dir + "/infra")
+	require.NoError(t, err)
+	assert.Equal(t, "master", branch)
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	head, err := repo.Head()
+	require.NoError(t, err)
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, worktree.Checkout(&git.CheckoutOptions{Hash: head.Hash()}))
+	_, err = getCurrentBranch(dir)
+	assert.ErrorContains(t, err, "HEAD is detached")
+	_, err = getCurrentBranch(t.TempDir())
+	assert.ErrorContains(t, err, errorHandlers.CommitErrorPrefix)
+}
+
+func TestCommitModuleUpdates(t *testing.T) {
+	dir, remoteDir := newTestRepoWithRemote(t)
+	require.NoError(t, os.WriteFile(dir+"/infra/main.tf", []byte("# v1.1.0\n"), os.ModePerm))
+	require.NoError(t, os.WriteFile(dir+"/notes.txt", []byte("unrelated change\n"), os.ModePerm))
+
+	commitHash, err := commitModuleUpdates(dir+"/infra", "upgrade/tf-modules-2024-11-05", []string{dir + "/infra/main.tf"}, "Update 1 terraform module(s)\n")
+	require.NoError(t, err)
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	head, err := repo.Head()
+	require.NoError(t, err)
+	assert.Equal(t, plumbing.NewBranchReferenceName("upgrade/tf-modules-2024-11-05"), head.Name(), "upgrade branch not checked out")
+	assert.Equal(t, commitHash, head.Hash())
+
+	commit, err := repo.CommitObject(commitHash)
+	require.NoError(t, err)
+	assert.Equal(t, "Update 1 terraform module(s)\n", commit.Message)
+	assert.Equal(t, defaultCommitAuthor, commit.Author.Name)
+	stats, err := commit.Stats()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(stats), "files other than the updated ones committed")
+	assert.Equal(t, "infra/main.tf", stats[0].Name)
+	notes, err := os.ReadFile(dir + "/notes.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "unrelated change\n", string(notes), "changes outside the updated files lost")
+
+	require.NoError(t, pushUpgradeBranch(context.Background(), resolver.New(resolver.Options{GitUser: "samwise", GitKey: "token"}), dir, git.DefaultRemoteName, "upgrade/tf-modules-2024-11-05"))
+	remote, err := git.PlainOpen(remoteDir)
+	require.NoError(t, err)
+	pushed, err := remote.Reference(plumbing.NewBranchReferenceName("upgrade/tf-modules-2024-11-05"), true)
+	require.NoError(t, err)
+	assert.Equal(t, commitHash, pushed.Hash(), "upgrade branch not pushed")
+	assert.NoError(t, pushUpgradeBranch(context.Background(), resolver.New(resolver.Options{}), dir, git.DefaultRemoteName, "upgrade/tf-modules-2024-11-05"), "pushing again failed")
+	assert.ErrorContains(t, pushUpgradeBranch(context.Background(), resolver.New(resolver.Options{}), dir, "upstream", "upgrade/tf-modules-2024-11-05"), errorHandlers.PushErrorPrefix)
+
+	// Committing again the same day adds to the existing branch
+	require.NoError(t, os.WriteFile(dir+"/infra/main.tf", []byte("# v1.2.0\n"), os.ModePerm))
+	nextCommitHash, err := commitModuleUpdates(dir, "upgrade/tf-modules-2024-11-05", []string{dir + "/infra/main.tf"}, "Update 1 terraform module(s)\n")
+	require.NoError(t, err)
+	nextCommit, err := repo.CommitObject(nextCommitHash)
+	require.NoError(t, err)
+	assert.Equal(t, []plumbing.Hash{commitHash}, nextCommit.ParentHashes)
+}
+
+func TestCheckoutBaseBranch(t *testing.T) {
+	dir, _ := newTestRepoWithRemote(t)
+	require.NoError(t, os.WriteFile(dir+"/notes.txt", []byte("unrelated change\n"), os.ModePerm))
+	require.NoError(t, os.WriteFile(dir+"/infra/main.tf", []byte("# v1.0.0\n# unrelated change\n"), os.ModePerm))
+	originals := map[string][]byte{dir + "/infra/main.tf": []byte("# v1.0.0\n# unrelated change\n"), dir + "/infra/new.tf": []byte("# v1.0.0\n")}
+	require.NoError(t, os.WriteFile(dir+"/infra/main.tf", []byte("# v1.1.0\n# unrelated change\n"), os.ModePerm))
+	require.NoError(t, os.WriteFile(dir+"/infra/new.tf", []byte("# v1.1.0\n"), os.ModePerm))
+	_, err := commitModuleUpdates(dir, "upgrade/tf-modules-vpc", []string{dir + "/infra/main.tf", dir + "/infra/new.tf"}, "update")
+	require.NoError(t, err)
+
+	require.NoError(t, checkoutBaseBranch(dir+"/infra", "master", originals))
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	head, err := repo.Head()
+	require.NoError(t, err)
+	assert.Equal(t, plumbing.NewBranchReferenceName("master"), head.Name(), "base branch not checked out")
+	for file, content := range originals {
+		restored, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, string(content), string(restored), file+" not restored")
+	}
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+	status, err := worktree.Status()
+	require.NoError(t, err)
+	// Only the changes from before the run are left, unstaged
+	assert.Equal(t, git.Modified, status.File("notes.txt").Worktree)
+	assert.Equal(t, git.Modified, status.File("infra/main.tf").Worktree)
+	assert.Equal(t, git.Unmodified, status.File("infra/main.tf").Staging)
+	assert.Equal(t, git.Untracked, status.File("infra/new.tf").Worktree)
+
+	// The next group is committed on top of the base branch
+	require.NoError(t, os.WriteFile(dir+"/infra/main.tf", []byte("# v2.0.0\n"), os.ModePerm))
+	commitHash, err := commitModuleUpdates(dir, "upgrade/tf-modules-major", []string{dir + "/infra/main.tf"}, "update")
+	require.NoError(t, err)
+	commit, err := repo.CommitObject(commitHash)
+	require.NoError(t, err)
+	assert.Equal(t, []plumbing.Hash{head.Hash()}, commit.ParentHashes)
+	stats, err := commit.Stats()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(stats), "files of the previous group committed")
+
+	assert.ErrorContains(t, checkoutBaseBranch(dir, "missing", nil), errorHandlers.CommitErrorPrefix+"unable to check out missing")
+}
+
+func TestCommitModuleUpdatesFailure(t *testing.T) {
+	dir, _ := newTestRepoWithRemote(t)
+	require.NoError(t, os.WriteFile(dir+"/infra/main.tf", []byte("# v1.1.0\n"), os.ModePerm))
+	require.NoError(t, os.WriteFile(dir+"/notes.txt", []byte("staged change\n"), os.ModePerm))
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+	_, err = worktree.Add("notes.txt")
+	require.NoError(t, err)
+	_, err = commitModuleUpdates(dir, "upgrade/tf-modules", []string{dir + "/infra/main.tf"}, "update")
+	assert.ErrorContains(t, err, "notes.txt was staged before the run")
+
+	_, err = commitModuleUpdates(dir, "upgrade/tf-modules", []string{t.TempDir() + "/main.tf"}, "update")
+	assert.ErrorContains(t, err, "is outside of the repository")
+	_, err = commitModuleUpdates(t.TempDir(), "upgrade/tf-modules", nil, "update")
+	assert.ErrorContains(t, err, errorHandlers.CommitErrorPrefix)
+}
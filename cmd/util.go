@@ -5,7 +5,9 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/rs/zerolog/log"
+	"io"
 	"os"
 	"path/filepath"
 	"slices"
@@ -21,29 +23,37 @@ import (
 	"github.com/hashicorp/terraform-exec/tfexec"
 	"github.com/thundersparkf/samwise/cmd/errorHandlers"
 	"github.com/thundersparkf/samwise/cmd/outputs"
+	"github.com/thundersparkf/samwise/pkg/resolver"
+	"github.com/thundersparkf/samwise/pkg/scanner"
 	"github.com/zclconf/go-cty/cty"
 )
 
 var FilesWritten []string
 
-type reportJson struct {
-	Report []jsonReport `json:"report"`
-}
-type jsonReport struct {
-	RepoLink         string `json:"repo"`
-	CurrentVersion   string `json:"current_version,omitempty"`
-	UpdatesAvailable string `json:"updates_available,omitempty"`
-	LatestVersion    string `json:"latest_version,omitempty"`
-	FileName         string `json:"file_name"`
-	Error            string `json:"error,omitempty"`
-}
+// Output filename that streams the report to stdout
+const stdoutFilename = "-"
 
-func Check(err error, message string, args ...any) {
-	if err != nil {
+// Output filename that streams the failure report to stderr when the report goes to stdout
+const stderrFilename = "-stderr"
 
-		log.Error().Msgf(message, "errorArgs", args)
-		panic(err)
-	}
+// Filename of the failure report next to the report
+const failureReportFilename = "failure_report"
+
+const failOnAny = "any"
+const failOnMinor = "minor"
+const failOnMajor = "major"
+const failOnFailure = "failure"
+
+// Update severities each --fail-on condition fails the run for
+var failOnSeverities = map[string][]string{
+	failOnAny:   {resolver.SeverityMajor, resolver.SeverityMinor, resolver.SeverityPatch},
+	failOnMinor: {resolver.SeverityMajor, resolver.SeverityMinor},
+	failOnMajor: {resolver.SeverityMajor},
+}
+var severityExitCodes = map[string]int{
+	resolver.SeverityMajor: errorHandlers.ExitCodeMajorUpdate,
+	resolver.SeverityMinor: errorHandlers.ExitCodeMinorUpdate,
+	resolver.SeverityPatch: errorHandlers.ExitCodePatchUpdate,
 }
 
 func CheckNonPanic(err error, message string, args ...any) bool {
@@ -55,67 +65,191 @@ func CheckNonPanic(err error, message string, args ...any) bool {
 	return false
 }
 
-func cleanUpSourceString(source string) string {
-	source = strings.ReplaceAll(source, "\"", "")
-	source = strings.ReplaceAll(source, " ", "")
-	return source
+func fixTrailingSlashForPath(path string) string {
+	if strings.HasSuffix(path, "/") {
+		return strings.TrimRight(path, "/")
+	}
+	return path
 }
-func generateReport(data []map[string]string, outputFilename string, outputFormat string, path string) {
+
+func generateReport(data []UpdateResult, failureList []UpdateResult, outputFilename string, outputFormat string, path string) error {
 	if outputFormat == outputs.CSV {
-		createCSVReportFile(data, path, outputFilename)
+		return createCSVReportFile(data, path, outputFilename)
 	} else if outputFormat == outputs.JSON {
-		createJSONReportFile(data, path, outputFilename)
-	} else {
-		Check(errors.New("output format "+outputFormat+"not available"), "")
+		return createJSONReportFile(data, path, outputFilename)
+	} else if outputFormat == outputs.MARKDOWN {
+		return createMarkdownReportFile(data, failureList, path, outputFilename)
+	} else if outputFormat == outputs.SARIF {
+		return createSARIFReportFile(data, failureList, path, outputFilename)
+	} else if outputFormat == outputs.JUNIT {
+		return createJUnitReportFile(data, failureList, path, outputFilename)
+	} else if outputFormat == outputs.HTML {
+		return createHTMLReportFile(data, failureList, path, outputFilename)
 	}
+	return errors.New(errorHandlers.CheckOutputFormatError)
+}
 
+// Writes the directories, files and module sources that failed in the same format as the report
+func generateFailureReport(failureList []UpdateResult, outputFilename string, outputFormat string, path string) error {
+	if outputFormat == outputs.CSV {
+		return createFailureCSVReportFile(failureList, path, outputFilename)
+	} else if outputFormat == outputs.JSON {
+		return createJSONReportFile(failureList, path, outputFilename)
+	} else if outputFormat == outputs.JUNIT {
+		// Every failure is an erroring test case
+		return createJUnitReportFile(failureList, nil, path, outputFilename)
+	}
+	return generateReport(nil, failureList, outputFilename, outputFormat, path)
 }
 
-func createCSVReportFile(data []map[string]string, path string, filename string) {
-	log.Debug().Msgf("creating " + path + "/" + filename + ".csv file")
-	log.Debug().Msgf("input data\n%v", data)
-	reportFilePath := path + "/" + filename + ".csv"
+// Writes to stdout without closing it once the report is written
+type stdoutWriter struct {
+	io.Writer
+}
+
+func (stdoutWriter) Close() error {
+	return nil
+}
+
+// Opens <path>/<filename>.<extension> for the report, or stdout(stderr) when filename is stdoutFilename(stderrFilename).
+// Returns the writer and where it writes to.
+func createReportWriter(path string, filename string, extension string) (io.WriteCloser, string, error) {
+	if filename == stdoutFilename {
+		return stdoutWriter{os.Stdout}, "stdout", nil
+	}
+	if filename == stderrFilename {
+		return stdoutWriter{os.Stderr}, "stderr", nil
+	}
+	reportFilePath := path + "/" + filename + "." + extension
 	report, err := os.Create(reportFilePath)
-	Check(err, "util :: createCSVReportFile :: unable to create file ", reportFilePath)
-	defer func(report *os.File) {
-		err := report.Close()
-		if err != nil {
-			Check(err, "util :: createCSVReportFile :: unable to close file")
-		}
-	}(report)
+	if err != nil {
+		return nil, reportFilePath, newReportError(reportFilePath, err)
+	}
+	return report, reportFilePath, nil
+}
 
+// Closes the report once it is written, the error of writing it takes precedence
+func closeReportWriter(report io.Closer, reportFilePath string, err error) error {
+	closeErr := report.Close()
+	if err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return newReportError(reportFilePath, closeErr)
+	}
+	return nil
+}
+
+func newReportError(reportFilePath string, err error) error {
+	return fmt.Errorf(errorHandlers.ReportErrorPrefix+"%s :: %w", reportFilePath, err)
+}
+
+// Directory reports are written to, the scanned directory unless outputDir is set
+func getOutputDir(outputDir string, rootDir string) (string, error) {
+	if outputDir == "" {
+		return rootDir, nil
+	}
+	err := os.MkdirAll(outputDir, os.ModePerm)
+	if err != nil {
+		return "", errors.New(errorHandlers.ReportErrorPrefix + err.Error())
+	}
+	return fixTrailingSlashForPath(outputDir), nil
+}
+
+func createCSVReportFile(data []UpdateResult, path string, filename string) error {
+	log.Debug().Msgf("creating " + path + "/" + filename + ".csv file")
+	report, reportFilePath, err := createReportWriter(path, filename, outputs.CSV)
+	if err != nil {
+		return err
+	}
+	err = writeCSVReport(data, report, reportFilePath)
+	return closeReportWriter(report, reportFilePath, err)
+}
+
+func writeCSVReport(data []UpdateResult, report io.Writer, reportFilePath string) error {
+	log.Debug().Msgf("input data\n%v", data)
 	writer := csv.NewWriter(report)
-	defer writer.Flush()
 	headers := []string{"repo", "current_version", "file_name"}
 	if LatestVersion {
 		headers = append(headers, "latest_version")
 	} else {
 		headers = append(headers, "updates_available")
 	}
-	err = writer.Write(headers)
-	Check(err, "unable to write headers to file", reportFilePath)
+	headers = append(headers, "latest_matching_version", "constraint_excludes_latest", "module_name", "submodule", "line", "column", "end_line", "end_column", "pinning")
+	err := writer.Write(headers)
+	if err != nil {
+		return newReportError(reportFilePath, err)
+	}
 	for _, row := range data {
 		log.Debug().Msgf("record: %v", row)
-		log.Debug().Msgf("record: %v", row)
-
-		if LatestVersion && len(row["latest_version"]) > 0 {
-			err = writer.Write([]string{row["repo"], row["current_version"], row["file_name"], row["latest_version"]})
-		} else {
-			if len(row["updates_available"]) > 0 {
-				err = writer.Write([]string{row["repo"], row["current_version"], row["file_name"], row["updates_available"]})
-			}
+		// Unpinned modules have no updates but are listed with the latest version they could be pinned to
+		if !row.HasUpdates() && !row.IsUnpinned() {
+			continue
+		}
+		repo := row.Repo
+		if MajorUpgrade && row.IsMajorUpgrade {
+			repo += majorUpgradeSuffix
 		}
-		Check(err, "util :: CreateCSVReportFile :: unable to write record to file", row["repo"], row["current_version"], row["updates_available"], row["file_name"])
-		writer.Flush()
+		updates := joinedUpdates(row)
+		if LatestVersion {
+			updates = row.LatestVersion
+		}
+		err = writer.Write([]string{repo, row.CurrentVersion, row.FileName, updates, row.LatestMatchingVersion, constraintExcludesLatestString(row),
+			row.Name, row.Submodule, formatPosition(row.Line), formatPosition(row.Column), formatPosition(row.EndLine), formatPosition(row.EndColumn), row.Pinning})
+		if err != nil {
+			return newReportError(reportFilePath, err)
+		}
+	}
+	writer.Flush()
+	if err = writer.Error(); err != nil {
+		return newReportError(reportFilePath, err)
+	}
+	log.Debug().Msgf("created " + reportFilePath)
+	return nil
+}
 
+func createFailureCSVReportFile(failureList []UpdateResult, path string, filename string) error {
+	report, reportFilePath, err := createReportWriter(path, filename, outputs.CSV)
+	if err != nil {
+		return err
+	}
+	err = writeFailureCSVReport(failureList, report, reportFilePath)
+	return closeReportWriter(report, reportFilePath, err)
+}
+
+// Writes a row per failure with where it happened, the source as written, the stage it failed at and its error code
+func writeFailureCSVReport(failureList []UpdateResult, report io.Writer, reportFilePath string) error {
+	writer := csv.NewWriter(report)
+	err := writer.Write([]string{"file_name", "line", "module_name", "source", "repo", "current_version", "error_stage", "error_code", "error"})
+	if err != nil {
+		return newReportError(reportFilePath, err)
+	}
+	for _, failure := range failureList {
+		err = writer.Write([]string{failure.FileName, formatPosition(failure.Line), failure.Name, failure.Source, failure.Repo, failure.CurrentVersion,
+			string(failure.ErrorStage), string(failure.ErrorCode), failure.Error})
+		if err != nil {
+			return newReportError(reportFilePath, err)
+		}
+	}
+	writer.Flush()
+	if err = writer.Error(); err != nil {
+		return newReportError(reportFilePath, err)
 	}
 	log.Debug().Msgf("created " + reportFilePath)
+	return nil
+}
 
+// Empty for unknown(zero) positions
+func formatPosition(position int) string {
+	if position == 0 {
+		return ""
+	}
+	return strconv.Itoa(position)
 }
 
 func checkOutputFormat(outputFormat string) (string, error) {
 	outputFormat = strings.ToLower(outputFormat)
-	outputsAvailable := []string{outputs.CSV, outputs.JSON}
+	outputsAvailable := []string{outputs.CSV, outputs.JSON, outputs.MARKDOWN, outputs.SARIF, outputs.JUNIT, outputs.HTML}
 	if !slices.Contains(outputsAvailable, outputFormat) {
 		return "", errors.New(errorHandlers.CheckOutputFormatError)
 	} else {
@@ -130,254 +264,157 @@ func checkOutputFilename(outputFilename string) string {
 
 }
 
-func createJSONReportFile(data []map[string]string, path string, filename string) {
-	reportFilePath := path + "/" + filename + ".json"
-	report, err := os.Create(reportFilePath)
-	Check(err, "unable to create file ", reportFilePath)
-	defer func(report *os.File) {
-		err := report.Close()
-		if err != nil {
-			Check(err, "util :: createJSONReportFile :: unable to close file")
-		}
-	}(report)
-	reportString, err := json.Marshal(data)
-	Check(err, "util :: createJSONReportFile :: unable to marshal modules data")
-	var reportJsonObject []jsonReport
-	err = json.Unmarshal(reportString, &reportJsonObject)
-	log.Debug().Msgf("util :: createJSONReportFile :: reportString :: " + string(reportString))
-	Check(err, "util :: createJSONReportFile :: unable unmarshal into output format")
-	var nonEmptyRecords []jsonReport
-	for _, value := range reportJsonObject {
-		if value.CurrentVersion != "" {
-			nonEmptyRecords = append(nonEmptyRecords, value)
-		}
+func createJSONReportFile(data []UpdateResult, path string, filename string) error {
+	report, reportFilePath, err := createReportWriter(path, filename, outputs.JSON)
+	if err != nil {
+		return err
 	}
-	finalReportMap := map[string][]jsonReport{"report": nonEmptyRecords}
-
-	reportOutputString, err := json.Marshal(finalReportMap)
-	Check(err, "unable to marshal finalReportMap")
-	_, err = report.Write(reportOutputString)
-	Check(err, "util :: createJSONReportFile :: unable to write to file", reportFilePath)
+	err = writeJSONReport(data, report, reportFilePath)
+	return closeReportWriter(report, reportFilePath, err)
 }
 
-func readTfFiles(path string) []string {
-	var sources = make([]string, 0)
-	content, _ := os.ReadFile(path)
-	file, _ := hclwrite.ParseConfig(content, path, hcl.Pos{Line: 1, Column: 1})
-	if file == nil {
-		return []string{}
-	}
-	for _, block := range file.Body().Blocks() {
-		labels := block.Labels()
-		if block.Type() == "module" && len(labels) > 0 {
-			if block.Body().GetAttribute("source") != nil {
-				sourceString := block.Body().GetAttribute("source").Expr().BuildTokens(nil).Bytes()
-				moduleSource := string(sourceString)
-				moduleSource = strings.ReplaceAll(moduleSource, "\"", "")
-				moduleSource = strings.ReplaceAll(moduleSource, " ", "")
-				log.Debug().Msgf("util :: readTfFiles :: sourceString :: %s", moduleSource)
-				sources = append(sources, moduleSource)
-			}
+func writeJSONReport(data []UpdateResult, report io.Writer, reportFilePath string) error {
+	finalReport := Report{SchemaVersion: ReportSchemaVersion, Report: make([]UpdateResult, 0)}
+	for _, value := range data {
+		// Always an array for consumers, even without updates
+		if value.UpdatesAvailable == nil {
+			value.UpdatesAvailable = make([]string, 0)
 		}
+		finalReport.Report = append(finalReport.Report, value)
+	}
+
+	log.Debug().Msgf("util :: createJSONReportFile :: report :: %v", finalReport)
+	encoder := json.NewEncoder(report)
+	// Keeps constraints like ~> 4.2 readable
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(finalReport); err != nil {
+		return newReportError(reportFilePath, err)
 	}
-	return sources
+	return nil
 }
 
-func updateTfFiles(path string, fileName string) []string {
-	log.Debug().Msgf("util :: updateTfFiles :: starting :: " + time.DateOnly)
+// Module block whose source ref ci moved to a newer tag
+type moduleBump struct {
+	FileName       string
+	Name           string
+	Repo           string
+	From           string
+	To             string
+	IsMajorUpgrade bool
+}
+
+// Module blocks of a file whose source refs ci moves, with the contents of the file before and after
+type tfFileUpdate struct {
+	FileName string
+	Before   []byte
+	After    []byte
+	Bumps    []moduleBump
+}
+
+// Works out the contents of the file with git module sources moved to their latest tag, in memory
+func getTfFileUpdate(ctx context.Context, moduleResolver *resolver.Resolver, path string, fileName string, include func(moduleBump) bool) (tfFileUpdate, error) {
+	log.Debug().Msgf("util :: getTfFileUpdate :: starting :: " + time.DateOnly)
 	fullPath := path + "/" + fileName
-	var sources = make([]string, 0)
+	update := tfFileUpdate{FileName: fullPath, Bumps: make([]moduleBump, 0)}
 
-	log.Debug().Msgf("util :: updateTfFiles :: reading file path :: %s", fullPath)
-	content, _ := os.ReadFile(fullPath)
+	log.Debug().Msgf("util :: getTfFileUpdate :: reading file path :: %s", fullPath)
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return update, fmt.Errorf(errorHandlers.ScanningErrorPrefix+"%w", err)
+	}
+	update.Before, update.After = content, content
 	file, _ := hclwrite.ParseConfig(content, fullPath, hcl.Pos{Line: 1, Column: 1})
 	if file == nil {
-		return []string{}
+		return update, nil
 	}
 	for _, block := range file.Body().Blocks() {
 		labels := block.Labels()
 		if block.Type() == "module" && len(labels) > 0 {
-			log.Debug().Msgf("util :: updateTfFiles :: module detected")
+			log.Debug().Msgf("util :: getTfFileUpdate :: module detected")
 			if block.Body().GetAttribute("source") != nil {
-				log.Debug().Msgf("util :: updateTfFiles :: module source detected")
+				log.Debug().Msgf("util :: getTfFileUpdate :: module source detected")
 				sourceString := block.Body().GetAttribute("source").Expr().BuildTokens(nil).Bytes()
-				moduleSource := string(sourceString)
-				moduleSource = cleanUpSourceString(moduleSource)
-				log.Debug().Msgf("util :: updateTfFiles :: sourceString :: %s", moduleSource)
-				moduleSource = extractModuleSource(moduleSource)
-				log.Debug().Msgf("util :: updateTfFiles :: moduleSource :: %s", moduleSource)
-				if moduleSource != "" {
-					sourceUrl, refTag, _ := extractRefAndPath(moduleSource)
-					if refTag == "" {
-						continue
-					}
-					log.Debug().Msgf("util :: updateTfFiles :: module data :: sourceUrl :: %s :: tag :: %s ", sourceUrl, refTag)
-					_, tagsList, _ := processGitRepo(sourceUrl, refTag)
-					largestTag := getGreatestSemverFromList(tagsList)
-					majorReleaseMismatch := isMajorReleaseUpgrade(refTag, largestTag)
-					if largestTag == "" {
-						continue
-					}
-					sources = append(sources, fullPath+";"+strconv.FormatBool(majorReleaseMismatch))
-					log.Debug().Msgf("util :: updateTfFiles :: file to be updated :: %s", fileName)
-					log.Debug().Msgf("util :: updateTfFiles :: tag to updated :: currentSource:: %s :: tag :: %s :: tagList :: %s", moduleSource, largestTag, tagsList)
-					currentSourceString := strings.Replace(moduleSource, refTag, largestTag, 1)
-					log.Debug().Msgf("util :: updateTfFiles :: updatedModule :: %s", currentSourceString)
-					writeHclBlockToFile(file, block, fullPath, "source", currentSourceString)
+				moduleSource := strings.ReplaceAll(strings.ReplaceAll(string(sourceString), "\"", ""), " ", "")
+				log.Debug().Msgf("util :: getTfFileUpdate :: sourceString :: %s", moduleSource)
+				sourceUrl, refTag, submodule := scanner.ParseGitSource(moduleSource)
+				// Commits are never bumped, nor are branches, whose history is left unresolved
+				if sourceUrl == "" || refTag == "" || scanner.GetPinning(refTag) == scanner.PinningCommit {
+					continue
 				}
+				log.Debug().Msgf("util :: getTfFileUpdate :: module data :: sourceUrl :: %s :: tag :: %s ", sourceUrl, refTag)
+				updateResult := moduleResolver.ResolveModuleVersions(ctx, ModuleUsage{Repo: sourceUrl, Submodule: submodule, SourceType: scanner.GitSourceType, CurrentVersion: refTag})
+				// Unpinned refs have a latest version but are left alone
+				largestTag := updateResult.LatestVersion
+				if !updateResult.HasUpdates() {
+					continue
+				}
+				bump := moduleBump{FileName: fullPath, Name: labels[0], Repo: sourceUrl, From: refTag, To: largestTag, IsMajorUpgrade: updateResult.IsMajorUpgrade}
+				if include != nil && !include(bump) {
+					continue
+				}
+				update.Bumps = append(update.Bumps, bump)
+				log.Debug().Msgf("util :: getTfFileUpdate :: file to be updated :: %s", fileName)
+				log.Debug().Msgf("util :: getTfFileUpdate :: tag to updated :: currentSource:: %s :: tag :: %s :: tagList :: %s", moduleSource, largestTag, joinedUpdates(updateResult))
+				currentSourceString := strings.Replace(moduleSource, "ref="+refTag, "ref="+largestTag, 1)
+				writeAttr := block.Body().SetAttributeValue("source", cty.StringVal(currentSourceString))
+				log.Debug().Msgf("util :: getTfFileUpdate :: updatedModule :: %s", string(writeAttr.Expr().BuildTokens(nil).Bytes()))
 			}
 		}
 	}
-	log.Debug().Msgf("util :: updateTfFiles :: sources :: %s", sources)
-
-	return sources
-}
-
-func writeHclBlockToFile(file *hclwrite.File, block *hclwrite.Block, path string, attr string, value any) {
-	writeAttr := block.Body().SetAttributeValue(attr, cty.StringVal(value.(string)))
-	log.Debug().Msgf("written to file :: writeAttr :: %s", string(writeAttr.Expr().BuildTokens(nil).Bytes()))
-	writeFile, err := os.OpenFile(path, os.O_WRONLY, os.ModePerm)
-	Check(err, "util :: updateTfFiles :: open file error")
-	_, err = writeFile.Write(file.Bytes())
-	Check(err, "util :: updateTfFiles :: write to file error")
-	log.Debug().Msgf("util :: updateTfFiles :: path of output :: %s :: file_bytes :: %s", writeFile.Name(), string(file.Bytes()))
-	err = writeFile.Close()
-	CheckNonPanic(err, "util :: updateTfFiles :: unable to close file")
-	log.Debug().Msgf("util :: updateTfFiles :: file closed")
-
-}
-
-func getGreatestSemverFromList(tagsList string) string {
-	if tagsList == "" {
-		return ""
-	}
-	highestTag := "v0.0.0"
-	tags := strings.Split(tagsList, "|")
-	for _, tag := range tags {
-		if getSemverGreaterThanCurrent(highestTag, tag) {
-			highestTag = tag
-		}
+	if len(update.Bumps) > 0 {
+		update.After = file.Bytes()
 	}
-
-	return highestTag
+	log.Debug().Msgf("util :: getTfFileUpdate :: sources :: %v", update.Bumps)
+	return update, nil
 }
 
-func getSemverGreaterThanCurrent(currentVersion string, versionToCheck string) bool {
-	currentVersionTag, err := version.NewVersion(currentVersion)
+// Replaces the contents of the file, keeping its permissions
+func writeTfFile(path string, content []byte) error {
+	err := os.WriteFile(path, content, os.ModePerm)
 	if err != nil {
-		return false
+		return errors.New("unable to update " + path + " " + err.Error())
 	}
-	versionToCheckTag, err := version.NewVersion(versionToCheck)
-	if err != nil {
-		return false
-	}
-	if versionToCheckTag.GreaterThan(currentVersionTag) {
-		return true
-	}
-	return false
+	log.Debug().Msgf("util :: writeTfFile :: path of output :: %s :: file_bytes :: %s", path, string(content))
+	return nil
+}
 
+func checkFailOn(failOn []string) ([]string, error) {
+	var conditions []string
+	for _, condition := range failOn {
+		condition = strings.ToLower(strings.TrimSpace(condition))
+		if !slices.Contains([]string{failOnAny, failOnMinor, failOnMajor, failOnFailure}, condition) {
+			return nil, errors.New(errorHandlers.FailOnError)
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions, nil
 }
 
-func isMajorReleaseUpgrade(currentVersion string, versionToCheck string) bool {
-	currentVersionTag, err := version.NewVersion(currentVersion)
-	if err != nil {
-		return false
+// Returns the exit code for the --fail-on and --require-pinned conditions met by the scan, zero when none are met
+func getExitCode(failOn []string, requirePinned bool, modules []UpdateResult, failureList []UpdateResult) int {
+	if slices.Contains(failOn, failOnFailure) && len(failureList) > 0 {
+		return errorHandlers.ExitCodeLookupFailure
 	}
-	versionToCheckTag, err := version.NewVersion(versionToCheck)
-	if err != nil {
-		return false
+	if requirePinned && len(getUnpinnedModules(modules)) > 0 {
+		return errorHandlers.ExitCodeUnpinned
 	}
-	versionToCheckTagMajorVersion := versionToCheckTag.Segments()[0]
-	currentVersionTagMajorVersion := currentVersionTag.Segments()[0]
-	if versionToCheckTagMajorVersion == currentVersionTagMajorVersion {
-		return false
+	severitiesFound := make(map[string]bool)
+	for _, module := range modules {
+		severitiesFound[module.UpgradeSeverity] = true
 	}
-	return true
-
-}
-
-/*
-	func writeCommit(repoPath string) error {
-		log.Debug().Msgf("util :: writeCommit :: opening repo")
-
-		repo, err := git.PlainOpen(repoPath)
-		CheckNonPanic(err, "util :: writeCommit :: failed to open repo")
-
-		//repo, err := git.Open(memory., memfs.New())
-		if CheckNonPanic(err, "util :: writeCommit :: unable to open repo") {
-			return err
-		}
-		currentTime := time.Now()
-		y, m, d := currentTime.Date()
-		branch := "upgrade/tf-modules-" + fmt.Sprintf("%d-%d-%d", y, m, d)
-		//err = repo.CreateBranch(&config.Branch{
-		//	Name: "upgrade/tf-modules-" + time.DateOnly,
-		//})
-
-		if CheckNonPanic(err, "util :: writeCommit :: unable to create upgrade/tf-modules-"+time.DateOnly) {
-			return err
+	for _, severity := range []string{resolver.SeverityMajor, resolver.SeverityMinor, resolver.SeverityPatch} {
+		if !severitiesFound[severity] {
+			continue
 		}
-
-		w, err := repo.Worktree()
-		Check(err, "util :: writeCommit :: worktree not fetched")
-		log.Debug().Msgf("util :: writeCommit :: branch :: %s", branch)
-
-		branchRefName := plumbing.NewBranchReferenceName(branch)
-		branchCoOpts := git.CheckoutOptions{
-			Branch: plumbing.ReferenceName(branchRefName),
-			Create: true,
-		}
-
-		if err := w.Checkout(&branchCoOpts); err != nil {
-			//Check(err, fmt.Sprintf("local checkout of branch '%s' failed, will attempt to fetch remote branch of same name.", branch))
-
-			//mirrorRemoteBranchRefSpec := fmt.Sprintf("refs/heads/%s:refs/heads/%s", branch, branch)
-			//err = fetchOrigin(r, mirrorRemoteBranchRefSpec)
-			//CheckIfError(err)
-
-			//err = w.Checkout(&branchCoOpts)
-			//CheckIfError(err)
-
-			branchCoOpts := git.CheckoutOptions{
-				Branch: plumbing.ReferenceName(branchRefName),
-				Create: false,
+		for _, condition := range failOn {
+			if slices.Contains(failOnSeverities[condition], severity) {
+				return severityExitCodes[severity]
 			}
-			err = w.Checkout(&branchCoOpts)
-			Check(err, "util :: writeCommit :: failed checkout of branch")
-
-		}
-		_, err = w.Add(".")
-		Check(err, "util :: writeCommit :: unable to add files")
-
-		_, err = w.Status()
-		if CheckNonPanic(err, "util :: writeCommit :: unable to fetch status") {
-			return err
 		}
-
-		// Commits the current staging area to the repository, with the new file
-		// just created. We should provide the object.Signature of Author of the
-		// commit Since version 5.0.1, we can omit the Author signature, being read
-		// from the git config files.
-		commit, err := w.Commit("[updates] | updates the terraform modules to the latest version upstream", &git.CommitOptions{
-			Author: &object.Signature{
-				Name: "samwise",
-				When: time.Now(),
-			},
-		})
-
-		if CheckNonPanic(err, "util :: writeCommit :: unable to add") {
-			return err
-		}
-
-		// Prints the current HEAD to verify that all worked well.
-		_, err = repo.CommitObject(commit)
-		if CheckNonPanic(err, "util :: writeCommit :: unable to add") {
-			return err
-		}
-		//repo.Push(&git.PushOptions{})
-		return nil
 	}
-*/
+	return 0
+}
+
 func removeDuplicateStr(strSlice []string) []string {
 	allKeys := make(map[string]bool)
 	list := []string{}
@@ -390,23 +427,24 @@ func removeDuplicateStr(strSlice []string) []string {
 	return list
 }
 
-func setupTerraform(workingDir string, tfVersion string) *tfexec.Terraform {
-
+func setupTerraform(workingDir string, tfVersion string) (*tfexec.Terraform, error) {
+	terraformVersion, err := version.NewVersion(tfVersion)
+	if err != nil {
+		return nil, err
+	}
 	installer := &releases.ExactVersion{
 		Product: product.Terraform,
-		Version: version.Must(version.NewVersion(tfVersion)),
+		Version: terraformVersion,
 	}
 
 	execPath, err := installer.Install(context.Background())
 	if err != nil {
-		log.Error().Msgf("error installing Terraform: %s", err.Error())
-		return nil
+		return nil, errors.New("unable to install terraform " + err.Error())
 	}
 
 	tf, err := tfexec.NewTerraform(workingDir, execPath)
 	if err != nil {
-		log.Error().Msgf("error running NewTerraform: %s", err.Error())
-		return nil
+		return nil, errors.New("unable to run terraform " + err.Error())
 	}
-	return tf
+	return tf, nil
 }
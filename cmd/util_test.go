@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"encoding/csv"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"github.com/rs/zerolog/log"
 	"io"
@@ -11,38 +13,45 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/thundersparkf/samwise/cmd/errorHandlers"
+	"github.com/thundersparkf/samwise/pkg/failure"
+	"github.com/thundersparkf/samwise/pkg/resolver"
+	"github.com/thundersparkf/samwise/pkg/scanner"
 )
 
 // Functions to help testing
-func readCsvFile(filePath string) [][]string {
+func readCsvFile(t *testing.T, filePath string) [][]string {
 	f, err := os.Open(filePath)
-	Check(err, "util :: readCSVFile :: unable to read input file", filePath)
+	require.NoError(t, err)
 	defer func(f *os.File) {
-		err := f.Close()
-		if err != nil {
-			Check(err, "util :: readCsvFile :: unable to close file")
-		}
+		require.NoError(t, f.Close())
 	}(f)
 
 	csvReader := csv.NewReader(f)
 	records, err := csvReader.ReadAll()
-	Check(err, "util :: readCSVFile :: unable to parse file as CSV", filePath)
+	require.NoError(t, err)
 
 	return records
 }
 
-func readJSONFile(filePath string) reportJson {
-	var report reportJson
-	file, err := os.Open(filePath)
-	Check(err, "util :: readJSONFile :: unable to open file", filePath)
-	byteValue, err := io.ReadAll(file)
-	Check(err, "util :: readJSONFile :: unable to read bytes", byteValue)
-	err = json.Unmarshal(byteValue, &report)
-	Check(err, "util :: readJSONFile :: unable to unmarshal json", string(byteValue))
+func readJSONFile(t *testing.T, filePath string) Report {
+	var report Report
+	byteValue, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(byteValue, &report))
 	return report
 }
 
+// Result of a module block with the given updates, the latest of them set as the latest version
+func newUpdateResult(repo string, currentVersion string, fileName string, updates ...string) UpdateResult {
+	return UpdateResult{
+		ModuleUsage:      ModuleUsage{Repo: repo, SourceType: scanner.GitSourceType, CurrentVersion: currentVersion, FileName: fileName},
+		UpdatesAvailable: updates,
+		LatestVersion:    resolver.GreatestVersion(updates),
+	}
+}
+
 func TestHappyCheckOutputFormat(t *testing.T) {
 	csvLowerCaseTest, err := checkOutputFormat("csv")
 	assert.Equal(t, "csv", csvLowerCaseTest)
@@ -74,96 +83,209 @@ func TestCheckOutputFilename(t *testing.T) {
 	assert.Equal(t, "", checkOutputFilename(".pdf"))
 }
 func TestGenerateReport(t *testing.T) {
-	data := []map[string]string{
-		{"repo": "github.com/test_repo", "current_version": "2.4.4", "updates_available": "2.7.7|2.7.8", "file_name": "main.tf"},
-		{"repo": "github.com/test_repo_1", "current_version": "3.2.1", "updates_available": "3.2.2|3.2.3", "file_name": "test/main.tf"},
+	data := []UpdateResult{
+		newUpdateResult("github.com/test_repo", "2.4.4", "main.tf", "2.7.7", "2.7.8"),
+		newUpdateResult("github.com/test_repo_1", "3.2.1", "test/main.tf", "3.2.2", "3.2.3"),
 	}
-	generateReport(data, "module_dependency_report", "csv", ".")
-	resultsCSV := readCsvFile("." + "/module_dependency_report.csv")
+	require.NoError(t, generateReport(data, nil, "module_dependency_report", "csv", "."))
+	resultsCSV := readCsvFile(t, "."+"/module_dependency_report.csv")
 	assert.Equal(t, len(resultsCSV), 3, "csv report unable to generated")
-	generateReport(data, "module_dependency", "json", ".")
-	resultsJSON := readJSONFile("./module_dependency.json")
+	require.NoError(t, generateReport(data, nil, "module_dependency", "json", "."))
+	resultsJSON := readJSONFile(t, "./module_dependency.json")
 	assert.Equal(t, len(resultsJSON.Report), 2, "json report unable to generated")
-	defer func() {
-		if r := recover(); r != nil {
-			assert.PanicsWithValue(t, "output format yaml not available", func() { generateReport(data, "module_dependency", "yaml", ".") }, "not panicking when incorrect output format is given")
-		}
-	}()
+	err := generateReport(data, nil, "module_dependency", "yaml", ".")
+	assert.EqualError(t, err, errorHandlers.CheckOutputFormatError, "no error when incorrect output format is given")
 }
 
 func TestGenerateFailureReport(t *testing.T) {
-	data := []map[string]string{
-		{"repo": "github.com/test_repo", "current_version": "2.4.4", "updates_available": "2.7.7|2.7.8", "file_name": "main.tf", "error": "random error"},
-		{"repo": "github.com/test_repo_1", "current_version": "3.2.1", "updates_available": "3.2.2|3.2.3", "file_name": "main.tf"},
+	data := []UpdateResult{
+		newUpdateResult("github.com/test_repo", "2.4.4", "main.tf", "2.7.7", "2.7.8"),
+		newUpdateResult("github.com/test_repo_1", "3.2.1", "main.tf", "3.2.2", "3.2.3"),
 	}
-	createJSONReportFile(data, ".", "failure_report")
-	results := readJSONFile("./failure_report.json")
+	data[0].Error = "random error"
+	outputDir := t.TempDir()
+	require.NoError(t, generateFailureReport(data, failureReportFilename, "json", outputDir))
+	results := readJSONFile(t, outputDir+"/failure_report.json")
 	assert.Equal(t, len(results.Report), 2)
-	assert.Equal(t, results.Report[0].RepoLink, data[0]["repo"], "repo_link key is not matching")
-	assert.Equal(t, results.Report[0].CurrentVersion, data[0]["current_version"], "current_version key is not matching")
-	assert.Equal(t, results.Report[0].UpdatesAvailable, data[0]["updates_available"], "updates_available key is not matching")
-	assert.Equal(t, results.Report[0].FileName, data[0]["file_name"], "file_name is not matching")
-	assert.Equal(t, results.Report[0].Error, data[0]["error"], "error key is not matching")
-	assert.Equal(t, results.Report[1].Error, data[1]["error"], "error key is not matching")
+	assert.Equal(t, ReportSchemaVersion, results.SchemaVersion)
+	assert.Equal(t, results.Report[0].Repo, data[0].Repo, "repo_link key is not matching")
+	assert.Equal(t, results.Report[0].CurrentVersion, data[0].CurrentVersion, "current_version key is not matching")
+	assert.Equal(t, results.Report[0].UpdatesAvailable, data[0].UpdatesAvailable, "updates_available key is not matching")
+	assert.Equal(t, results.Report[0].FileName, data[0].FileName, "file_name is not matching")
+	assert.Equal(t, results.Report[0].Error, data[0].Error, "error key is not matching")
+	assert.Equal(t, results.Report[1].Error, data[1].Error, "error key is not matching")
+}
 
+func TestGenerateFailureReportFormats(t *testing.T) {
+	lookupFailure := UpdateResult{ModuleUsage: ModuleUsage{Name: "vpc", Source: "git::https://github.com/org/vpc.git?ref=main", Repo: "https://github.com/org/vpc.git", CurrentVersion: "main", FileName: "main.tf", Line: 3},
+		Error: resolver.VersionParsingErrorPrefix + "main", ErrorStage: failure.StageTagParse, ErrorCode: failure.ErrorCodeInvalidVersion}
+	scanFailure := newScanFailure("modules/broken.tf", failure.NewFailure(failure.StageParse, failure.ErrorCodeInvalidHCL, errors.New("invalid block")))
+	failureList := []UpdateResult{lookupFailure, scanFailure}
+	outputDir := t.TempDir()
+
+	require.NoError(t, generateFailureReport(failureList, failureReportFilename, "csv", outputDir))
+	rows := readCsvFile(t, outputDir+"/failure_report.csv")
+	assert.Equal(t, []string{"file_name", "line", "module_name", "source", "repo", "current_version", "error_stage", "error_code", "error"}, rows[0])
+	assert.Equal(t, []string{"main.tf", "3", "vpc", "git::https://github.com/org/vpc.git?ref=main", "https://github.com/org/vpc.git", "main", "tag-parse", "E_INVALID_VERSION", "unable to parse version main"}, rows[1])
+	assert.Equal(t, []string{"modules/broken.tf", "", "", "", "", "", "parse", "E_INVALID_HCL", errorHandlers.ScanningErrorPrefix + "invalid block"}, rows[2])
+
+	require.NoError(t, generateFailureReport(failureList, failureReportFilename, "junit", outputDir))
+	content, err := os.ReadFile(outputDir + "/failure_report.xml")
+	require.NoError(t, err)
+	var testSuites junitTestSuites
+	require.NoError(t, xml.Unmarshal(content, &testSuites))
+	assert.Equal(t, 2, testSuites.Errors, "failures are not erroring test cases")
+	assert.Equal(t, "modules/broken.tf", testSuites.TestSuites[1].TestCases[0].Name)
+	assert.Equal(t, "E_INVALID_HCL", testSuites.TestSuites[1].TestCases[0].Error.Type)
+	assert.Equal(t, "unable to scan modules/broken.tf", testSuites.TestSuites[1].TestCases[0].Error.Message)
+
+	require.NoError(t, generateFailureReport(failureList, failureReportFilename, "markdown", outputDir))
+	content, err = os.ReadFile(outputDir + "/failure_report.md")
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "| `vpc` | `git::https://github.com/org/vpc.git?ref=main` | `main.tf:3` | `main` | tag-parse | `E_INVALID_VERSION` |")
+
+	require.NoError(t, generateFailureReport(failureList, failureReportFilename, "sarif", outputDir))
+	content, err = os.ReadFile(outputDir + "/failure_report.sarif")
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "modules/broken.tf failed at the parse stage(E_INVALID_HCL)")
+
+	stderr := os.Stderr
+	reader, writer, err := os.Pipe()
+	require.NoError(t, err)
+	os.Stderr = writer
+	require.NoError(t, generateFailureReport(failureList, stderrFilename, "csv", outputDir))
+	os.Stderr = stderr
+	require.NoError(t, writer.Close())
+	output, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Contains(t, string(output), "main.tf,3,vpc,")
+	_, err = os.Stat(outputDir + "/" + stderrFilename + ".csv")
+	assert.True(t, os.IsNotExist(err), "failure report written to a file instead of stderr")
 }
+
 func TestHappyCreateCSVReportFile(t *testing.T) {
-	data := []map[string]string{
-		{"repo": "github.com/test_repo", "current_version": "2.4.4", "updates_available": "2.7.7|2.7.8", "file_name": "main.tf"},
-		{"repo": "github.com/test_repo_1", "current_version": "3.2.1", "updates_available": "3.2.2|3.2.3", "file_name": "main.tf"},
+	data := []UpdateResult{
+		newUpdateResult("github.com/test_repo", "2.4.4", "main.tf", "2.7.7", "2.7.8"),
+		newUpdateResult("github.com/test_repo_1", "3.2.1", "main.tf", "3.2.2", "3.2.3"),
+		newUpdateResult("github.com/test_repo_2", "1.0.0", "main.tf"),
 	}
-	createCSVReportFile(data, ".", "module_report")
-	results := readCsvFile("." + "/module_report.csv")
-	assert.Equal(t, len(results), 3)
-	assert.Equal(t, data[0]["repo"], results[1][0], "repo link mismatch")
-	assert.Equal(t, data[0]["current_version"], results[1][1], "current_version mismatch")
-	assert.Equal(t, data[0]["updates_available"], results[1][3], "updates_available mismatch")
-	assert.Equal(t, data[0]["file_name"], results[1][2], "file_name mismatch")
+	require.NoError(t, createCSVReportFile(data, ".", "module_report"))
+	results := readCsvFile(t, "."+"/module_report.csv")
+	assert.Equal(t, len(results), 3, "module without updates written to report")
+	assert.Equal(t, data[0].Repo, results[1][0], "repo link mismatch")
+	assert.Equal(t, data[0].CurrentVersion, results[1][1], "current_version mismatch")
+	assert.Equal(t, "2.7.7|2.7.8", results[1][3], "updates_available mismatch")
+	assert.Equal(t, data[0].FileName, results[1][2], "file_name mismatch")
 
 }
 
 func TestHappyCreateCSVReportFileLatestVersion(t *testing.T) {
-	data := []map[string]string{
-		{"repo": "github.com/test_repo", "current_version": "2.4.4", "latest_version": "2.7.8", "file_name": "main.tf"},
-		{"repo": "github.com/test_repo_1", "current_version": "3.2.1", "latest_version": "3.2.3", "file_name": "test/main.tf"},
+	data := []UpdateResult{
+		newUpdateResult("github.com/test_repo", "2.4.4", "main.tf", "2.7.7", "2.7.8"),
+		newUpdateResult("github.com/test_repo_1", "3.2.1", "test/main.tf", "3.2.2", "3.2.3"),
 	}
 	// Set cobra flag for testing
 	LatestVersion = true
-	createCSVReportFile(data, ".", "module_report")
-	results := readCsvFile("." + "/module_report.csv")
+	require.NoError(t, createCSVReportFile(data, ".", "module_report"))
+	results := readCsvFile(t, "."+"/module_report.csv")
 	log.Info().Msgf("results: %v", results)
 	assert.Equal(t, len(results), 3)
-	assert.Equal(t, data[0]["repo"], results[1][0], "repo link mismatch")
-	assert.Equal(t, data[0]["current_version"], results[1][1], "current_version mismatch")
-	assert.Equal(t, data[0]["latest_version"], results[1][3], "latest_version mismatch")
-	assert.Equal(t, data[0]["file_name"], results[1][2], "file_name mismatch")
+	assert.Equal(t, data[0].Repo, results[1][0], "repo link mismatch")
+	assert.Equal(t, data[0].CurrentVersion, results[1][1], "current_version mismatch")
+	assert.Equal(t, "2.7.8", results[1][3], "latest_version mismatch")
+	assert.Equal(t, data[0].FileName, results[1][2], "file_name mismatch")
+	LatestVersion = false
 
 }
 
 func TestUnhappyCreateCSVReportFileNoData(t *testing.T) {
-	var data = make([]map[string]string, 0)
-	createCSVReportFile(data, ".", "module_dependency_report")
-	results := readCsvFile("." + "/module_dependency_report.csv")
+	var data = make([]UpdateResult, 0)
+	require.NoError(t, createCSVReportFile(data, ".", "module_dependency_report"))
+	results := readCsvFile(t, "."+"/module_dependency_report.csv")
 	assert.Equal(t, len(results), 1)
 
 }
 
 // TODO: Add test case to ensure only non-empty "updates_available" values get written to report
 func TestUnhappyCreateCSVReportFileNilData(t *testing.T) {
-	createCSVReportFile(nil, ".", "module_dependency_report")
-	results := readCsvFile("." + "/module_dependency_report.csv")
+	require.NoError(t, createCSVReportFile(nil, ".", "module_dependency_report"))
+	results := readCsvFile(t, "."+"/module_dependency_report.csv")
 	assert.Equal(t, len(results), 1)
 
 }
 
-func TestCheckError(t *testing.T) {
-	defer func() {
-		if r := recover(); r != nil {
-			assert.NotPanics(t, nil, func() { Check(nil, "testing non panic case") })
-			assert.PanicsWithValue(t, "test error", func() { Check(errors.New("test error"), "error testing") })
-		}
-	}()
+func TestCreateReportFileStdout(t *testing.T) {
+	data := []UpdateResult{newUpdateResult("github.com/test_repo", "2.4.4", "main.tf", "2.7.7", "2.7.8")}
+	LatestVersion = false
+	stdout := os.Stdout
+	reader, writer, err := os.Pipe()
+	require.NoError(t, err)
+	os.Stdout = writer
+	require.NoError(t, createCSVReportFile(data, t.TempDir(), stdoutFilename))
+	require.NoError(t, createJSONReportFile(data, t.TempDir(), stdoutFilename))
+	os.Stdout = stdout
+	require.NoError(t, writer.Close())
+	output, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Contains(t, string(output), "repo,current_version,file_name,updates_available")
+	assert.Contains(t, string(output), "github.com/test_repo,2.4.4,main.tf,2.7.7|2.7.8")
+	assert.Contains(t, string(output), `{"schema_version":"`+ReportSchemaVersion+`","report":[{"name":"","repo":"github.com/test_repo"`)
+	_, err = os.Stat("-.csv")
+	assert.True(t, os.IsNotExist(err), "report written to a file named -")
+}
 
+func TestWriteJSONReport(t *testing.T) {
+	var report bytes.Buffer
+	lookupFailure := UpdateResult{ModuleUsage: ModuleUsage{Name: "test", Source: "github.com/test_repo?ref=2.4.4", Repo: "github.com/test_repo", SourceType: scanner.GitSourceType, CurrentVersion: "2.4.4", FileName: "main.tf", Line: 2, Column: 3, EndLine: 2, EndColumn: 45},
+		Error: "random error", ErrorStage: failure.StageNetwork, ErrorCode: failure.ErrorCodeUnreachable}
+	unpinned := UpdateResult{ModuleUsage: ModuleUsage{Repo: "github.com/unpinned", SourceType: scanner.GitSourceType, Pinning: scanner.PinningNone, FileName: "main.tf"}, LatestVersion: "v1.1.0"}
+	require.NoError(t, writeJSONReport([]UpdateResult{lookupFailure, unpinned}, &report, "buffer"))
+	assert.Equal(t, `{"schema_version":"1.4.0","report":[{"name":"test","source":"github.com/test_repo?ref=2.4.4","repo":"github.com/test_repo","source_type":"git","current_version":"2.4.4","file_name":"main.tf","line":2,"column":3,"end_line":2,"end_column":45,"updates_available":[],"is_major_upgrade":false,"error":"random error","error_stage":"network","error_code":"E_UNREACHABLE"},`+
+		`{"name":"","repo":"github.com/unpinned","source_type":"git","current_version":"","pinning":"none","file_name":"main.tf","updates_available":[],"latest_version":"v1.1.0","is_major_upgrade":false}]}`+"\n", report.String(), "unpinned module dropped")
+
+	report.Reset()
+	require.NoError(t, writeJSONReport([]UpdateResult{newScanFailure("modules/broken.tf", failure.NewFailure(failure.StageParse, failure.ErrorCodeUnreadable, errors.New("permission denied")))}, &report, "buffer"))
+	assert.Equal(t, `{"schema_version":"1.4.0","report":[{"name":"","repo":"","source_type":"","current_version":"","file_name":"modules/broken.tf","updates_available":[],"is_major_upgrade":false,"error":"`+errorHandlers.ScanningErrorPrefix+`permission denied","error_stage":"parse","error_code":"E_UNREADABLE"}]}`+"\n", report.String(), "scan failure dropped")
+
+	report.Reset()
+	excluded := true
+	constraint := newUpdateResult("terraform-aws-modules/vpc/aws", "~> 4.2", "main.tf", "4.9.1", "5.0.0")
+	constraint.SourceType, constraint.Submodule = scanner.RegistrySourceType, "modules/vpc-endpoints"
+	constraint.LatestMatchingVersion, constraint.ConstraintExcludesLatest = "4.9.1", &excluded
+	constraint.UpgradeSeverity, constraint.IsMajorUpgrade = resolver.SeverityMajor, true
+	require.NoError(t, writeJSONReport([]UpdateResult{constraint}, &report, "buffer"))
+	assert.Equal(t, `{"schema_version":"1.4.0","report":[{"name":"","repo":"terraform-aws-modules/vpc/aws","source_type":"registry","current_version":"~> 4.2","submodule":"modules/vpc-endpoints","file_name":"main.tf","updates_available":["4.9.1","5.0.0"],"latest_version":"5.0.0","latest_matching_version":"4.9.1","constraint_excludes_latest":true,"upgrade_severity":"major","is_major_upgrade":true}]}`+"\n", report.String())
+}
+
+func TestGetOutputDir(t *testing.T) {
+	scannedDir, err := getOutputDir("", "./scanned")
+	require.NoError(t, err)
+	assert.Equal(t, "./scanned", scannedDir, "scanned directory not used by default")
+	outputDir := t.TempDir() + "/reports/nested"
+	createdDir, err := getOutputDir(outputDir+"/", "./scanned")
+	require.NoError(t, err)
+	assert.Equal(t, outputDir, createdDir)
+	info, err := os.Stat(outputDir)
+	assert.Empty(t, err, "output directory not created")
+	assert.True(t, info.IsDir())
+
+	require.NoError(t, createCSVReportFile(nil, outputDir, "module_report"))
+	results := readCsvFile(t, outputDir+"/module_report.csv")
+	assert.Equal(t, len(results), 1)
+}
+
+func TestCreateReportFileError(t *testing.T) {
+	missingDir := t.TempDir() + "/missing"
+	for _, outputFormat := range []string{"csv", "json", "markdown", "sarif", "junit", "html"} {
+		err := generateReport(nil, nil, "module_report", outputFormat, missingDir)
+		assert.ErrorIs(t, err, os.ErrNotExist, outputFormat+" report error not returned")
+		assert.Contains(t, err.Error(), errorHandlers.ReportErrorPrefix+missingDir+"/module_report.")
+	}
+
+	file := t.TempDir() + "/main.tf"
+	require.NoError(t, os.WriteFile(file, nil, os.ModePerm))
+	_, err := getOutputDir(file+"/reports", "./scanned")
+	assert.Contains(t, err.Error(), errorHandlers.ReportErrorPrefix, "output directory below a file created")
 }
 
 func TestCheckNonPanic(t *testing.T) {
@@ -174,57 +296,87 @@ func TestCheckNonPanic(t *testing.T) {
 }
 
 func TestHappyCreateJSONReportFileNoData(t *testing.T) {
-	data := []map[string]string{
-		{"repo": "github.com/test_repo", "current_version": "2.4.4", "updates_available": "2.7.7|2.7.8", "file_name": "main.tf"},
-		{"repo": "github.com/test_repo_1", "current_version": "3.2.1", "updates_available": "3.2.2|3.2.3", "file_name": "test/main.tf"},
+	data := []UpdateResult{
+		newUpdateResult("github.com/test_repo", "2.4.4", "main.tf", "2.7.7", "2.7.8"),
+		newUpdateResult("github.com/test_repo_1", "3.2.1", "test/main.tf", "3.2.2", "3.2.3"),
 	}
-	createJSONReportFile(data, ".", "module_dependency")
-	results := readJSONFile("." + "/module_dependency.json")
+	require.NoError(t, createJSONReportFile(data, ".", "module_dependency"))
+	results := readJSONFile(t, "."+"/module_dependency.json")
 	assert.Equal(t, len(results.Report), 2)
-	assert.Equal(t, results.Report[0].RepoLink, data[0]["repo"], "repo_link key is not matching")
-	assert.Equal(t, results.Report[0].CurrentVersion, data[0]["current_version"], "current_version key is not matching")
-	assert.Equal(t, results.Report[0].UpdatesAvailable, data[0]["updates_available"], "updates_available key is not matching")
-	assert.Equal(t, results.Report[0].FileName, data[0]["file_name"], "file_name key is not matching")
+	assert.Equal(t, results.Report[0].Repo, data[0].Repo, "repo_link key is not matching")
+	assert.Equal(t, results.Report[0].CurrentVersion, data[0].CurrentVersion, "current_version key is not matching")
+	assert.Equal(t, results.Report[0].UpdatesAvailable, data[0].UpdatesAvailable, "updates_available key is not matching")
+	assert.Equal(t, results.Report[0].FileName, data[0].FileName, "file_name key is not matching")
 
 }
 
 func TestUnhappyCreateJSONReportFileNoData(t *testing.T) {
-	var data = make([]map[string]string, 0)
-	var expectedReport = reportJson{[]jsonReport(nil)}
-	createJSONReportFile(data, ".", "module_dependency_report")
-	results := readJSONFile("." + "/module_dependency_report.json")
+	var data = make([]UpdateResult, 0)
+	var expectedReport = Report{SchemaVersion: ReportSchemaVersion, Report: []UpdateResult{}}
+	require.NoError(t, createJSONReportFile(data, ".", "module_dependency_report"))
+	results := readJSONFile(t, "."+"/module_dependency_report.json")
 	assert.Equal(t, expectedReport, results, "report not empty")
 	assert.Empty(t, results.Report, "reports are non-zero")
 }
 
-func TestReadTfFiles(t *testing.T) {
-
-	// TESTS TO READ FILE AND CHECK MODULE SOURCES
-
+func TestHappyCreateCSVReportFileConstraint(t *testing.T) {
+	excluded := true
+	constraint := newUpdateResult("terraform-aws-modules/vpc/aws", "~> 4.2", "main.tf", "4.9.1", "5.0.0")
+	constraint.LatestMatchingVersion, constraint.ConstraintExcludesLatest = "4.9.1", &excluded
+	constraint.IsMajorUpgrade = true
+	constraint.Name, constraint.Submodule = "vpc", "modules/vpc-endpoints"
+	constraint.Line, constraint.Column, constraint.EndLine, constraint.EndColumn = 3, 3, 3, 57
+	constraint.Pinning = scanner.PinningVersion
+	unpinned := UpdateResult{ModuleUsage: ModuleUsage{Name: "eks", Repo: "github.com/org/eks", CurrentVersion: "main", Pinning: scanner.PinningBranch, FileName: "main.tf", Line: 8}, LatestVersion: "v1.1.0"}
+	data := []UpdateResult{constraint, unpinned, newUpdateResult("github.com/org/s3", "v1.0.0", "main.tf")}
+	LatestVersion = false
+	MajorUpgrade = true
+	require.NoError(t, createCSVReportFile(data, ".", "module_report"))
+	MajorUpgrade = false
+	results := readCsvFile(t, "."+"/module_report.csv")
+	assert.Equal(t, len(results), 3)
+	assert.Equal(t, []string{"repo", "current_version", "file_name", "updates_available", "latest_matching_version", "constraint_excludes_latest", "module_name", "submodule", "line", "column", "end_line", "end_column", "pinning"}, results[0])
+	assert.Equal(t, []string{"github.com/org/eks", "main", "main.tf", "", "", "", "eks", "", "8", "", "", "", "branch"}, results[2], "unpinned module not listed")
+	assert.Equal(t, "terraform-aws-modules/vpc/aws[MAJOR UPGRADE AVAILABLE]", results[1][0], "major upgrade not highlighted with --major")
+	assert.Equal(t, "4.9.1", results[1][4], "latest_matching_version mismatch")
+	assert.Equal(t, "true", results[1][5], "constraint_excludes_latest mismatch")
+	assert.Equal(t, []string{"vpc", "modules/vpc-endpoints", "3", "3", "3", "57", "version"}, results[1][6:], "module block columns mismatch")
 }
 
-func TestGetGreatestSemverFromList(t *testing.T) {
-	list1 := "1.0.0|1.0.1|1.0.5|1.0.3-beta|1.0.3-alpha"
-	list2 := "1.0.0|v1.0.1|v1.0.3-beta|v1.0.5-alpha"
-	list3 := "1.0.0|1.0.1|1.0.3-beta|1.0.5-alpha|v1.0.5-beta"
-	list4 := "1.0.dwd0|1.0wvwv.1|1.0.3-beta|1.0.5-alpha|v1.0.5-beta"
-
-	assert.Equal(t, "1.0.5", getGreatestSemverFromList(list1))
-	assert.Equal(t, "v1.0.5-alpha", getGreatestSemverFromList(list2))
-	assert.Equal(t, "v1.0.5-beta", getGreatestSemverFromList(list3))
-	assert.Equal(t, "v1.0.5-beta", getGreatestSemverFromList(list4))
-
+func TestCheckFailOn(t *testing.T) {
+	conditions, err := checkFailOn([]string{"Major", " failure"})
+	assert.Empty(t, err)
+	assert.Equal(t, []string{failOnMajor, failOnFailure}, conditions)
+	conditions, err = checkFailOn(nil)
+	assert.Empty(t, err)
+	assert.Empty(t, conditions)
+	_, err = checkFailOn([]string{"major", "sometimes"})
+	assert.EqualError(t, err, errorHandlers.FailOnError)
 }
 
-func TestIsMajorReleaseUpgrade(t *testing.T) {
-	minorUpgrade := isMajorReleaseUpgrade("1.2.1", "1.3.2-beta")
-	assert.Equal(t, false, minorUpgrade)
-	majorUpgrade := isMajorReleaseUpgrade("2.2.1", "1.3.2-beta")
-	assert.Equal(t, true, majorUpgrade)
-	failureCurrentVersion := isMajorReleaseUpgrade("random", "1.3.2-beta")
-	assert.Equal(t, false, failureCurrentVersion)
-	failureVersionToCheck := isMajorReleaseUpgrade("1.1.1", "1.3..2-beta")
-	assert.Equal(t, false, failureVersionToCheck)
+func TestGetExitCode(t *testing.T) {
+	patchModules := []UpdateResult{{UpgradeSeverity: resolver.SeverityPatch}, {}}
+	minorModules := append([]UpdateResult{{UpgradeSeverity: resolver.SeverityMinor}}, patchModules...)
+	majorModules := append([]UpdateResult{{UpgradeSeverity: resolver.SeverityMajor}}, minorModules...)
+	failureList := []UpdateResult{{ModuleUsage: ModuleUsage{Repo: "github.com/test_repo_4"}, Error: "random error"}}
+
+	assert.Equal(t, 0, getExitCode(nil, false, majorModules, failureList), "exit code without fail-on")
+	assert.Equal(t, errorHandlers.ExitCodePatchUpdate, getExitCode([]string{failOnAny}, false, patchModules, nil))
+	assert.Equal(t, 0, getExitCode([]string{failOnMinor}, false, patchModules, nil), "patch updates fail minor")
+	assert.Equal(t, errorHandlers.ExitCodeMinorUpdate, getExitCode([]string{failOnMinor}, false, minorModules, nil))
+	assert.Equal(t, errorHandlers.ExitCodeMinorUpdate, getExitCode([]string{failOnAny}, false, minorModules, nil))
+	assert.Equal(t, 0, getExitCode([]string{failOnMajor}, false, minorModules, nil), "minor updates fail major")
+	assert.Equal(t, errorHandlers.ExitCodeMajorUpdate, getExitCode([]string{failOnMajor}, false, majorModules, nil))
+	assert.Equal(t, errorHandlers.ExitCodeMajorUpdate, getExitCode([]string{failOnAny}, false, majorModules, failureList), "failures fail without the failure condition")
+	assert.Equal(t, errorHandlers.ExitCodeLookupFailure, getExitCode([]string{failOnMajor, failOnFailure}, false, majorModules, failureList))
+	assert.Equal(t, 0, getExitCode([]string{failOnFailure}, false, majorModules, nil))
+
+	unpinnedModules := append([]UpdateResult{{ModuleUsage: ModuleUsage{Pinning: scanner.PinningBranch}}}, majorModules...)
+	assert.Equal(t, errorHandlers.ExitCodeMajorUpdate, getExitCode([]string{failOnAny}, false, unpinnedModules, nil), "unpinned modules fail without require-pinned")
+	assert.Equal(t, errorHandlers.ExitCodeUnpinned, getExitCode([]string{failOnAny}, true, unpinnedModules, nil))
+	assert.Equal(t, errorHandlers.ExitCodeUnpinned, getExitCode(nil, true, unpinnedModules, nil))
+	assert.Equal(t, 0, getExitCode(nil, true, majorModules, nil))
+	assert.Equal(t, errorHandlers.ExitCodeLookupFailure, getExitCode([]string{failOnFailure}, true, unpinnedModules, failureList))
 }
 
 func TestRemoveDuplicateStr(t *testing.T) {
@@ -236,7 +388,8 @@ func TestRemoveDuplicateStr(t *testing.T) {
 }
 
 func TestSetupTerraform(t *testing.T) {
-	tf := setupTerraform(".", "1.9.8")
+	tf, err := setupTerraform(".", "1.9.8")
+	require.NoError(t, err)
 	log.Debug().Msgf("tf file execPath: %s \n workingDir:,%s", tf.ExecPath(), tf.WorkingDir())
 	tfVersion, _, err := tf.Version(context.TODO(), true)
 	assert.Empty(t, err)
@@ -249,7 +402,8 @@ func TestSetupTerraform(t *testing.T) {
 }
 
 func TestFailureSetupTerraform(t *testing.T) {
-	tf := setupTerraform(".", "1.9.8testtest")
+	tf, err := setupTerraform(".", "1.9.8testtest")
 	assert.Empty(t, tf)
+	assert.NotEmpty(t, err)
 
 }
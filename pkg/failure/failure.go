@@ -0,0 +1,67 @@
+// Package failure categorises the errors of module sources by the stage they failed at and a stable error code
+package failure
+
+import "errors"
+
+// Stage is the step a module source failed at, from reading the terraform files to comparing its versions
+type Stage string
+
+const StageParse Stage = "parse"
+const StageURLNormalize Stage = "url-normalize"
+const StageAuth Stage = "auth"
+const StageNetwork Stage = "network"
+const StageTagParse Stage = "tag-parse"
+
+// ErrorCode is the kind of failure within a stage, stable across releases for consumers of the failure report
+type ErrorCode string
+
+// parse: a directory or file that can't be read, a file that isn't valid HCL
+const ErrorCodeUnreadable ErrorCode = "E_UNREADABLE"
+const ErrorCodeInvalidHCL ErrorCode = "E_INVALID_HCL"
+
+// url-normalize: a source url or registry address that can't be turned into a url to query
+const ErrorCodeInvalidURL ErrorCode = "E_INVALID_URL"
+
+// auth: the ssh key can't be read or parsed, the remote or registry rejected the credentials
+const ErrorCodeSSHKey ErrorCode = "E_SSH_KEY"
+const ErrorCodeAuthFailed ErrorCode = "E_AUTH_FAILED"
+
+// network: the repository or registry module doesn't exist, the host can't be reached or the registry answered with
+// an unexpected status
+const ErrorCodeNotFound ErrorCode = "E_NOT_FOUND"
+const ErrorCodeUnreachable ErrorCode = "E_UNREACHABLE"
+const ErrorCodeRegistry ErrorCode = "E_REGISTRY"
+
+// tag-parse: the registry response can't be decoded, the version in use is neither a version nor a constraint, the
+// commit or branch in use isn't in the repository
+const ErrorCodeInvalidResponse ErrorCode = "E_INVALID_RESPONSE"
+const ErrorCodeInvalidVersion ErrorCode = "E_INVALID_VERSION"
+const ErrorCodeUnknownRef ErrorCode = "E_UNKNOWN_REF"
+
+// Failure is an error of a module source along with the stage it failed at
+type Failure struct {
+	Stage Stage
+	Code  ErrorCode
+	Err   error
+}
+
+func (f *Failure) Error() string {
+	return f.Err.Error()
+}
+
+func (f *Failure) Unwrap() error {
+	return f.Err
+}
+
+func NewFailure(stage Stage, code ErrorCode, err error) error {
+	return &Failure{Stage: stage, Code: code, Err: err}
+}
+
+// FailureOf returns the stage and code of the first Failure in the chain of err, both empty when there is none
+func FailureOf(err error) (Stage, ErrorCode) {
+	var f *Failure
+	if errors.As(err, &f) {
+		return f.Stage, f.Code
+	}
+	return "", ""
+}
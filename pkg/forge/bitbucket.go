@@ -0,0 +1,167 @@
+package forge
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+const DefaultBitbucketURL = "https://api.bitbucket.org/2.0"
+
+// Bitbucket opens pull requests through the Bitbucket Cloud REST API
+type Bitbucket struct {
+	options Options
+}
+
+// BitbucketServer opens pull requests through the REST API of a Bitbucket Server or Data Center instance
+type BitbucketServer struct {
+	options Options
+}
+
+type bitbucketPullRequest struct {
+	ID    int `json:"id"`
+	Links struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+type bitbucketPullRequests struct {
+	Values []bitbucketPullRequest `json:"values"`
+}
+
+type bitbucketServerPullRequest struct {
+	ID      int `json:"id"`
+	Version int `json:"version"`
+	ToRef   struct {
+		ID string `json:"id"`
+	} `json:"toRef"`
+	Links struct {
+		Self []struct {
+			Href string `json:"href"`
+		} `json:"self"`
+	} `json:"links"`
+}
+
+type bitbucketServerPullRequests struct {
+	Values []bitbucketServerPullRequest `json:"values"`
+}
+
+func NewBitbucket(options Options) *Bitbucket {
+	return &Bitbucket{options: options.withDefaults(DefaultBitbucketURL)}
+}
+
+// NewBitbucketServer fails without a base url, there is no public instance to default to
+func NewBitbucketServer(options Options) (*BitbucketServer, error) {
+	if options.BaseURL == "" {
+		return nil, errors.New("forge " + BitbucketServerForge + " needs the url of its REST API, e.g. https://bitbucket.example.com/rest/api/1.0")
+	}
+	return &BitbucketServer{options: options.withDefaults("")}, nil
+}
+
+func bitbucketHeaders(options Options) map[string]string {
+	return map[string]string{"Accept": "application/json", "Authorization": options.authorization()}
+}
+
+// Neither Bitbucket has pull request labels
+func warnBitbucketLabels(pullRequest PullRequest) {
+	if len(pullRequest.Labels) > 0 {
+		log.Warn().Msgf("bitbucket :: OpenPullRequest :: pull requests don't have labels, leaving out %s", strings.Join(pullRequest.Labels, ", "))
+	}
+}
+
+// OpenPullRequest opens the pull request or updates the open one, replacing its reviewers
+func (b *Bitbucket) OpenPullRequest(ctx context.Context, pullRequest PullRequest) (PullRequestResult, error) {
+	workspace, name, err := splitRepository(pullRequest.Repository)
+	if err != nil {
+		return PullRequestResult{}, err
+	}
+	warnBitbucketLabels(pullRequest)
+	pullRequestsUrl := b.options.BaseURL + "/repositories/" + url.PathEscape(workspace) + "/" + url.PathEscape(name) + "/pullrequests"
+	query := url.Values{"state": {"OPEN"}, "q": {`source.branch.name="` + pullRequest.Head + `" AND destination.branch.name="` + pullRequest.Base + `"`}}
+	var open bitbucketPullRequests
+	err = doJSON(ctx, b.options.HTTPClient, http.MethodGet, pullRequestsUrl+"?"+query.Encode(), bitbucketHeaders(b.options), nil, &open)
+	if err != nil {
+		return PullRequestResult{}, err
+	}
+	content := map[string]any{"title": pullRequest.Title, "description": pullRequest.Body}
+	if len(pullRequest.Reviewers) > 0 {
+		// Users are looked up by {uuid} or account id, Bitbucket Cloud doesn't take usernames
+		reviewers := make([]map[string]string, 0, len(pullRequest.Reviewers))
+		for _, reviewer := range pullRequest.Reviewers {
+			if strings.HasPrefix(reviewer, "{") {
+				reviewers = append(reviewers, map[string]string{"uuid": reviewer})
+			} else {
+				reviewers = append(reviewers, map[string]string{"account_id": reviewer})
+			}
+		}
+		content["reviewers"] = reviewers
+	}
+	var opened bitbucketPullRequest
+	if len(open.Values) > 0 {
+		log.Debug().Msgf("bitbucket :: OpenPullRequest :: updating :: %s#%d", pullRequest.Repository, open.Values[0].ID)
+		err = doJSON(ctx, b.options.HTTPClient, http.MethodPut, pullRequestsUrl+"/"+strconv.Itoa(open.Values[0].ID), bitbucketHeaders(b.options), content, &opened)
+		return PullRequestResult{Number: opened.ID, URL: opened.Links.HTML.Href}, err
+	}
+	content["source"] = map[string]any{"branch": map[string]string{"name": pullRequest.Head}}
+	content["destination"] = map[string]any{"branch": map[string]string{"name": pullRequest.Base}}
+	log.Debug().Msgf("bitbucket :: OpenPullRequest :: creating :: %s :: %s -> %s", pullRequest.Repository, pullRequest.Head, pullRequest.Base)
+	err = doJSON(ctx, b.options.HTTPClient, http.MethodPost, pullRequestsUrl, bitbucketHeaders(b.options), content, &opened)
+	return PullRequestResult{Number: opened.ID, URL: opened.Links.HTML.Href, Created: true}, err
+}
+
+// Web page of the pull request from its self links
+func (pullRequest bitbucketServerPullRequest) url() string {
+	if len(pullRequest.Links.Self) == 0 {
+		return ""
+	}
+	return pullRequest.Links.Self[0].Href
+}
+
+// OpenPullRequest opens the pull request or updates the open one, replacing its reviewers with the usernames
+func (b *BitbucketServer) OpenPullRequest(ctx context.Context, pullRequest PullRequest) (PullRequestResult, error) {
+	project, name, err := splitRepository(strings.TrimPrefix(strings.Trim(pullRequest.Repository, "/"), "scm/"))
+	if err != nil {
+		return PullRequestResult{}, err
+	}
+	warnBitbucketLabels(pullRequest)
+	pullRequestsUrl := b.options.BaseURL + "/projects/" + url.PathEscape(project) + "/repos/" + url.PathEscape(name) + "/pull-requests"
+	headRef, baseRef := "refs/heads/"+pullRequest.Head, "refs/heads/"+pullRequest.Base
+	query := url.Values{"state": {"OPEN"}, "direction": {"OUTGOING"}, "at": {headRef}, "limit": {"100"}}
+	var open bitbucketServerPullRequests
+	err = doJSON(ctx, b.options.HTTPClient, http.MethodGet, pullRequestsUrl+"?"+query.Encode(), bitbucketHeaders(b.options), nil, &open)
+	if err != nil {
+		return PullRequestResult{}, err
+	}
+	content := map[string]any{"title": pullRequest.Title, "description": pullRequest.Body}
+	if len(pullRequest.Reviewers) > 0 {
+		reviewers := make([]map[string]any, 0, len(pullRequest.Reviewers))
+		for _, reviewer := range pullRequest.Reviewers {
+			reviewers = append(reviewers, map[string]any{"user": map[string]string{"name": reviewer}})
+		}
+		content["reviewers"] = reviewers
+	}
+	var opened bitbucketServerPullRequest
+	for _, existing := range open.Values {
+		if existing.ToRef.ID != baseRef {
+			continue
+		}
+		log.Debug().Msgf("bitbucket :: OpenPullRequest :: updating :: %s#%d", pullRequest.Repository, existing.ID)
+		// Updates are checked against the version of the pull request they were made from
+		content["version"] = existing.Version
+		err = doJSON(ctx, b.options.HTTPClient, http.MethodPut, pullRequestsUrl+"/"+strconv.Itoa(existing.ID), bitbucketHeaders(b.options), content, &opened)
+		return PullRequestResult{Number: opened.ID, URL: opened.url()}, err
+	}
+	// Both refs are in the repository, forks aren't opened from
+	repository := map[string]any{"slug": name, "project": map[string]string{"key": project}}
+	content["fromRef"], content["toRef"] = map[string]any{"id": headRef, "repository": repository}, map[string]any{"id": baseRef, "repository": repository}
+	log.Debug().Msgf("bitbucket :: OpenPullRequest :: creating :: %s :: %s -> %s", pullRequest.Repository, pullRequest.Head, pullRequest.Base)
+	err = doJSON(ctx, b.options.HTTPClient, http.MethodPost, pullRequestsUrl, bitbucketHeaders(b.options), content, &opened)
+	return PullRequestResult{Number: opened.ID, URL: opened.url(), Created: true}, err
+}
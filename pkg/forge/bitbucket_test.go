@@ -0,0 +1,149 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Functions to help testing
+
+// Starts a stand-in Bitbucket API serving the pull requests of one repository at pullRequestsPath, listing them as
+// listJson does and returning them as pullRequestJson does, returns its url and the pull requests. Pull requests
+// checkCreate returns an error for are rejected like the API would, when it isn't nil.
+func newTestBitbucket(t *testing.T, pullRequestsPath string, listJson func(r *http.Request, pullRequests map[int]map[string]any) string, pullRequestJson func(id int) string, checkCreate func(pullRequest map[string]any) error) (string, map[int]map[string]any) {
+	pullRequests := make(map[int]map[string]any)
+	mux := http.NewServeMux()
+	mux.HandleFunc(pullRequestsPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Basic dXNlcjphcHAtcGFzc3dvcmQ=" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(listJson(r, pullRequests)))
+			return
+		}
+		var pullRequest map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&pullRequest))
+		if checkCreate != nil {
+			if err := checkCreate(pullRequest); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"errors": [{"message": "` + err.Error() + `"}]}`))
+				return
+			}
+		}
+		id := len(pullRequests) + 1
+		pullRequests[id] = pullRequest
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(pullRequestJson(id)))
+	})
+	mux.HandleFunc("PUT "+pullRequestsPath+"/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		var update map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&update))
+		for field, value := range update {
+			pullRequests[id][field] = value
+		}
+		_, _ = w.Write([]byte(pullRequestJson(id)))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server.URL, pullRequests
+}
+
+func TestBitbucketOpenPullRequest(t *testing.T) {
+	pullRequestJson := func(id int) string {
+		return `{"id": ` + strconv.Itoa(id) + `, "links": {"html": {"href": "https://bitbucket.org/org/infra/pull-requests/` + strconv.Itoa(id) + `"}}}`
+	}
+	baseUrl, pullRequests := newTestBitbucket(t, "/2.0/repositories/org/infra/pullrequests", func(r *http.Request, pullRequests map[int]map[string]any) string {
+		for id := range pullRequests {
+			if r.URL.Query().Get("q") == `source.branch.name="upgrade" AND destination.branch.name="main"` {
+				return `{"values": [` + pullRequestJson(id) + `]}`
+			}
+		}
+		return `{"values": []}`
+	}, pullRequestJson, nil)
+	bitbucket, err := New(BitbucketForge, Options{BaseURL: baseUrl + "/2.0", Username: "user", Token: "app-password"})
+	require.NoError(t, err)
+	pullRequest := PullRequest{Repository: "org/infra", Head: "upgrade", Base: "main", Title: "Update 1 terraform module(s)", Body: "vpc", Labels: []string{"dependencies"}, Reviewers: []string{"{a1b2}", "557058:c0ffee"}}
+
+	result, err := bitbucket.OpenPullRequest(context.Background(), pullRequest)
+	require.NoError(t, err)
+	assert.Equal(t, PullRequestResult{Number: 1, URL: "https://bitbucket.org/org/infra/pull-requests/1", Created: true}, result)
+	assert.Equal(t, map[string]any{
+		"title":       "Update 1 terraform module(s)",
+		"description": "vpc",
+		"source":      map[string]any{"branch": map[string]any{"name": "upgrade"}},
+		"destination": map[string]any{"branch": map[string]any{"name": "main"}},
+		"reviewers":   []any{map[string]any{"uuid": "{a1b2}"}, map[string]any{"account_id": "557058:c0ffee"}},
+	}, pullRequests[1])
+
+	pullRequest.Body = "vpc, eks"
+	result, err = bitbucket.OpenPullRequest(context.Background(), pullRequest)
+	require.NoError(t, err)
+	assert.False(t, result.Created, "open pull request not reused")
+	assert.Len(t, pullRequests, 1)
+	assert.Equal(t, "vpc, eks", pullRequests[1]["description"])
+
+	_, err = NewBitbucket(Options{BaseURL: baseUrl + "/2.0", Token: "app-password"}).OpenPullRequest(context.Background(), pullRequest)
+	assert.ErrorContains(t, err, "401 Unauthorized")
+}
+
+func TestBitbucketServerOpenPullRequest(t *testing.T) {
+	pullRequestJson := func(id int) string {
+		return `{"id": ` + strconv.Itoa(id) + `, "version": 3, "toRef": {"id": "refs/heads/main"}, "links": {"self": [{"href": "https://bitbucket.example.com/projects/INFRA/repos/live/pull-requests/` + strconv.Itoa(id) + `"}]}}`
+	}
+	baseUrl, pullRequests := newTestBitbucket(t, "/rest/api/1.0/projects/INFRA/repos/live/pull-requests", func(r *http.Request, pullRequests map[int]map[string]any) string {
+		// Pull requests from the branch into other branches are left alone
+		values := []string{`{"id": 99, "version": 0, "toRef": {"id": "refs/heads/release"}}`}
+		for id := range pullRequests {
+			if r.URL.Query().Get("at") == "refs/heads/upgrade" && r.URL.Query().Get("direction") == "OUTGOING" {
+				values = append(values, pullRequestJson(id))
+			}
+		}
+		return `{"values": [` + strings.Join(values, ",") + `]}`
+	}, pullRequestJson, func(pullRequest map[string]any) error {
+		// Refs are looked up in the repository they name, which has to be the one the pull request is opened in
+		for _, field := range []string{"fromRef", "toRef"} {
+			ref, _ := pullRequest[field].(map[string]any)
+			repository, _ := ref["repository"].(map[string]any)
+			project, _ := repository["project"].(map[string]any)
+			if repository["slug"] != "live" || project["key"] != "INFRA" {
+				return errors.New(field + " is not a ref of INFRA/live")
+			}
+		}
+		return nil
+	})
+	_, err := New(BitbucketServerForge, Options{})
+	assert.ErrorContains(t, err, "needs the url of its REST API")
+	bitbucketServer, err := New(BitbucketServerForge, Options{BaseURL: baseUrl + "/rest/api/1.0/", Username: "user", Token: "app-password"})
+	require.NoError(t, err)
+	pullRequest := PullRequest{Repository: "scm/INFRA/live", Head: "upgrade", Base: "main", Title: "Update 1 terraform module(s)", Body: "vpc", Reviewers: []string{"jdoe"}}
+
+	result, err := bitbucketServer.OpenPullRequest(context.Background(), pullRequest)
+	require.NoError(t, err)
+	assert.Equal(t, PullRequestResult{Number: 1, URL: "https://bitbucket.example.com/projects/INFRA/repos/live/pull-requests/1", Created: true}, result)
+	assert.Equal(t, map[string]any{
+		"title":       "Update 1 terraform module(s)",
+		"description": "vpc",
+		"fromRef":     map[string]any{"id": "refs/heads/upgrade", "repository": map[string]any{"slug": "live", "project": map[string]any{"key": "INFRA"}}},
+		"toRef":       map[string]any{"id": "refs/heads/main", "repository": map[string]any{"slug": "live", "project": map[string]any{"key": "INFRA"}}},
+		"reviewers":   []any{map[string]any{"user": map[string]any{"name": "jdoe"}}},
+	}, pullRequests[1])
+
+	pullRequest.Body = "vpc, eks"
+	result, err = bitbucketServer.OpenPullRequest(context.Background(), pullRequest)
+	require.NoError(t, err)
+	assert.False(t, result.Created, "open pull request not reused")
+	assert.Len(t, pullRequests, 1)
+	assert.Equal(t, "vpc, eks", pullRequests[1]["description"])
+	assert.Equal(t, 3.0, pullRequests[1]["version"], "update not made from the version of the pull request")
+}
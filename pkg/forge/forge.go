@@ -0,0 +1,181 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/thundersparkf/samwise/pkg/resolver"
+)
+
+const GitHubForge = "github"
+const GitLabForge = "gitlab"
+const BitbucketForge = "bitbucket"
+const BitbucketServerForge = "bitbucket-server"
+
+// Forge opens pull requests on a git hosting service
+type Forge interface {
+	// OpenPullRequest opens a pull request from the head branch into the base branch, or updates the title and body of
+	// the one already open between them
+	OpenPullRequest(ctx context.Context, pullRequest PullRequest) (PullRequestResult, error)
+}
+
+// PullRequest is the pull request to open or update
+type PullRequest struct {
+	// Path of the repository on the forge, e.g. org/infra
+	Repository string
+	// Branch the changes are on
+	Head string
+	// Branch the changes are merged into
+	Base  string
+	Title string
+	Body  string
+	// Added to the pull request, left out on forges without labels
+	Labels []string
+	// Usernames asked for a review, account ids or {uuid}s on Bitbucket Cloud
+	Reviewers []string
+}
+
+// PullRequestResult is the pull request opened or updated
+type PullRequestResult struct {
+	Number int
+	URL    string
+	// False when an open pull request was updated instead
+	Created bool
+}
+
+// Options of the forge clients
+type Options struct {
+	// Base url of the forge API, the default of the forge when empty. Allows self-hosted forges and stand-in servers.
+	BaseURL string
+	Token   string
+	// Sent with Token as basic auth when set, for forges authenticating with app passwords. Token is a bearer token
+	// otherwise.
+	Username string
+	// Client the API is called with, one with a 30s timeout when nil
+	HTTPClient *http.Client
+}
+
+// Names of the supported forges
+var Forges = []string{GitHubForge, GitLabForge, BitbucketForge, BitbucketServerForge}
+
+// New returns the client of the forge with the given name
+func New(name string, options Options) (Forge, error) {
+	switch name {
+	case GitHubForge:
+		return NewGitHub(options), nil
+	case GitLabForge:
+		return NewGitLab(options), nil
+	case BitbucketForge:
+		return NewBitbucket(options), nil
+	case BitbucketServerForge:
+		return NewBitbucketServer(options)
+	}
+	return nil, errors.New("forge " + name + " not supported. Please use " + strings.Join(Forges, ", "))
+}
+
+// TagURL returns the web page of the tag of a git repository on the public forges, empty for other hosts
+func TagURL(repo string, tag string) string {
+	repo = resolver.NormalizeRepoUrl(repo)
+	segments := strings.Split(tag, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	tag = strings.Join(segments, "/")
+	host, _, _ := strings.Cut(repo, "/")
+	switch host {
+	case "github.com":
+		return "https://" + repo + "/releases/tag/" + tag
+	case "gitlab.com":
+		return "https://" + repo + "/-/tags/" + tag
+	case "bitbucket.org":
+		return "https://" + repo + "/src/" + tag
+	}
+	return ""
+}
+
+// Options with the default base url and http client for the ones left out
+func (options Options) withDefaults(defaultBaseURL string) Options {
+	if options.BaseURL == "" {
+		options.BaseURL = defaultBaseURL
+	}
+	options.BaseURL = strings.TrimSuffix(options.BaseURL, "/")
+	if options.HTTPClient == nil {
+		options.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return options
+}
+
+// Authorization header value of the options, basic auth when there is a username
+func (options Options) authorization() string {
+	if options.Username != "" {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(options.Username+":"+options.Token))
+	}
+	if options.Token != "" {
+		return "Bearer " + options.Token
+	}
+	return ""
+}
+
+// Splits the repository into the owner(workspace or project) and the name, failing when it isn't <owner>/<name>
+func splitRepository(repository string) (string, string, error) {
+	owner, name, ok := strings.Cut(strings.Trim(repository, "/"), "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", errors.New("repository " + repository + " is not <owner>/<name>")
+	}
+	return owner, name, nil
+}
+
+// Calls the API with the request and decodes the response into target, either of them skipped when nil
+func doJSON(ctx context.Context, client *http.Client, method string, requestUrl string, headers map[string]string, request any, target any) error {
+	var body io.Reader
+	if request != nil {
+		requestJson, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(requestJson)
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, method, requestUrl, body)
+	if err != nil {
+		return err
+	}
+	if request != nil {
+		httpRequest.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range headers {
+		if value != "" {
+			httpRequest.Header.Set(name, value)
+		}
+	}
+	log.Debug().Msgf("forge :: doJSON :: %s :: %s", method, requestUrl)
+	response, err := client.Do(httpRequest)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := response.Body.Close(); err != nil {
+			log.Error().Msgf("forge :: doJSON :: unable to close response body :: %s", err.Error())
+		}
+	}()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return errors.New(method + " " + requestUrl + " returned " + response.Status + " " + strings.TrimSpace(string(message)))
+	}
+	if target == nil {
+		return nil
+	}
+	if err = json.NewDecoder(response.Body).Decode(target); err != nil {
+		return fmt.Errorf("%s %s :: %w", method, requestUrl, err)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package forge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagURL(t *testing.T) {
+	assert.Equal(t, "https://github.com/org/vpc/releases/tag/v1.2.0", TagURL("git::https://github.com/org/vpc.git", "v1.2.0"))
+	assert.Equal(t, "https://github.com/org/vpc/releases/tag/v1.2.0", TagURL("git@github.com:org/vpc.git", "v1.2.0"))
+	assert.Equal(t, "https://gitlab.com/group/sub/vpc/-/tags/modules/vpc/1.2.0", TagURL("gitlab.com/group/sub/vpc", "modules/vpc/1.2.0"))
+	assert.Equal(t, "https://bitbucket.org/org/vpc/src/v1.2.0+build", TagURL("bitbucket.org/org/vpc", "v1.2.0+build"))
+	assert.Empty(t, TagURL("git::https://git.example.com/org/vpc.git", "v1.2.0"))
+}
+
+func TestNew(t *testing.T) {
+	forge, err := New(GitHubForge, Options{})
+	assert.NoError(t, err)
+	assert.IsType(t, &GitHub{}, forge)
+	forge, err = New(GitLabForge, Options{})
+	assert.NoError(t, err)
+	assert.IsType(t, &GitLab{}, forge)
+	_, err = New("gitea", Options{})
+	assert.ErrorContains(t, err, "forge gitea not supported")
+}
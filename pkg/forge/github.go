@@ -0,0 +1,72 @@
+package forge
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+)
+
+const DefaultGitHubURL = "https://api.github.com"
+
+// GitHub opens pull requests through the GitHub REST API, github.com or GitHub Enterprise with the /api/v3 base url
+type GitHub struct {
+	options Options
+}
+
+type gitHubPullRequest struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+}
+
+func NewGitHub(options Options) *GitHub {
+	return &GitHub{options: options.withDefaults(DefaultGitHubURL)}
+}
+
+func (g *GitHub) headers() map[string]string {
+	return map[string]string{"Accept": "application/vnd.github+json", "X-GitHub-Api-Version": "2022-11-28", "Authorization": g.options.authorization()}
+}
+
+// OpenPullRequest opens the pull request or updates the open one, adding to its labels and reviewers
+func (g *GitHub) OpenPullRequest(ctx context.Context, pullRequest PullRequest) (PullRequestResult, error) {
+	owner, name, err := splitRepository(pullRequest.Repository)
+	if err != nil {
+		return PullRequestResult{}, err
+	}
+	repoUrl := g.options.BaseURL + "/repos/" + owner + "/" + name
+	query := url.Values{"state": {"open"}, "head": {owner + ":" + pullRequest.Head}, "base": {pullRequest.Base}}
+	var open []gitHubPullRequest
+	err = doJSON(ctx, g.options.HTTPClient, http.MethodGet, repoUrl+"/pulls?"+query.Encode(), g.headers(), nil, &open)
+	if err != nil {
+		return PullRequestResult{}, err
+	}
+	var opened gitHubPullRequest
+	content := map[string]string{"title": pullRequest.Title, "body": pullRequest.Body}
+	if len(open) > 0 {
+		log.Debug().Msgf("github :: OpenPullRequest :: updating :: %s#%d", pullRequest.Repository, open[0].Number)
+		err = doJSON(ctx, g.options.HTTPClient, http.MethodPatch, repoUrl+"/pulls/"+strconv.Itoa(open[0].Number), g.headers(), content, &opened)
+	} else {
+		content["head"], content["base"] = pullRequest.Head, pullRequest.Base
+		log.Debug().Msgf("github :: OpenPullRequest :: creating :: %s :: %s -> %s", pullRequest.Repository, pullRequest.Head, pullRequest.Base)
+		err = doJSON(ctx, g.options.HTTPClient, http.MethodPost, repoUrl+"/pulls", g.headers(), content, &opened)
+	}
+	result := PullRequestResult{Number: opened.Number, URL: opened.HTMLURL, Created: len(open) == 0}
+	if err != nil {
+		return result, err
+	}
+	// Pull requests share the labels of issues
+	if len(pullRequest.Labels) > 0 {
+		labels := map[string][]string{"labels": pullRequest.Labels}
+		err = doJSON(ctx, g.options.HTTPClient, http.MethodPost, repoUrl+"/issues/"+strconv.Itoa(opened.Number)+"/labels", g.headers(), labels, nil)
+		if err != nil {
+			return result, err
+		}
+	}
+	if len(pullRequest.Reviewers) > 0 {
+		reviewers := map[string][]string{"reviewers": pullRequest.Reviewers}
+		err = doJSON(ctx, g.options.HTTPClient, http.MethodPost, repoUrl+"/pulls/"+strconv.Itoa(opened.Number)+"/requested_reviewers", g.headers(), reviewers, nil)
+	}
+	return result, err
+}
@@ -0,0 +1,126 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Functions to help testing
+
+// Starts a stand-in GitHub API for org/infra keeping the pull requests opened, with their labels and reviewers joined
+// by commas, returns its url and the pull requests
+func newTestGitHub(t *testing.T, token string) (string, map[int]map[string]string) {
+	pulls := make(map[int]map[string]string)
+	mux := http.NewServeMux()
+	addToPull := func(field string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			number, _ := strconv.Atoi(r.PathValue("number"))
+			var values map[string][]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&values))
+			for _, value := range values[field] {
+				if !slices.Contains(strings.Split(pulls[number][field], ","), value) {
+					pulls[number][field] = strings.TrimPrefix(pulls[number][field]+","+value, ",")
+				}
+			}
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}
+	mux.HandleFunc("POST /repos/org/infra/issues/{number}/labels", addToPull("labels"))
+	mux.HandleFunc("POST /repos/org/infra/pulls/{number}/requested_reviewers", addToPull("reviewers"))
+	mux.HandleFunc("/repos/org/infra/pulls", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message": "Bad credentials"}`))
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			var open []string
+			for number, pull := range pulls {
+				if r.URL.Query().Get("head") == "org:"+pull["head"] && r.URL.Query().Get("base") == pull["base"] {
+					open = append(open, `{"number": `+strconv.Itoa(number)+`, "html_url": "https://github.com/org/infra/pull/`+strconv.Itoa(number)+`"}`)
+				}
+			}
+			_, _ = w.Write([]byte("[" + strings.Join(open, ",") + "]"))
+		case http.MethodPost:
+			var pull map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&pull))
+			number := len(pulls) + 1
+			pulls[number] = pull
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"number": ` + strconv.Itoa(number) + `, "html_url": "https://github.com/org/infra/pull/` + strconv.Itoa(number) + `"}`))
+		}
+	})
+	mux.HandleFunc("PATCH /repos/org/infra/pulls/{number}", func(w http.ResponseWriter, r *http.Request) {
+		number, _ := strconv.Atoi(r.PathValue("number"))
+		pull, ok := pulls[number]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		var update map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&update))
+		pull["title"], pull["body"] = update["title"], update["body"]
+		_, _ = w.Write([]byte(`{"number": ` + strconv.Itoa(number) + `, "html_url": "https://github.com/org/infra/pull/` + strconv.Itoa(number) + `"}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server.URL, pulls
+}
+
+func TestGitHubOpenPullRequest(t *testing.T) {
+	baseUrl, pulls := newTestGitHub(t, "token")
+	github, err := New(GitHubForge, Options{BaseURL: baseUrl + "/", Token: "token"})
+	require.NoError(t, err)
+	pullRequest := PullRequest{Repository: "org/infra", Head: "upgrade/tf-modules-2024-11-05", Base: "main", Title: "Update 1 terraform module(s)", Body: "vpc"}
+
+	result, err := github.OpenPullRequest(context.Background(), pullRequest)
+	require.NoError(t, err)
+	assert.Equal(t, PullRequestResult{Number: 1, URL: "https://github.com/org/infra/pull/1", Created: true}, result)
+	assert.Equal(t, map[string]string{"title": "Update 1 terraform module(s)", "body": "vpc", "head": "upgrade/tf-modules-2024-11-05", "base": "main"}, pulls[1])
+
+	pullRequest.Title, pullRequest.Body = "Update 2 terraform module(s)", "vpc, eks"
+	result, err = github.OpenPullRequest(context.Background(), pullRequest)
+	require.NoError(t, err)
+	assert.Equal(t, PullRequestResult{Number: 1, URL: "https://github.com/org/infra/pull/1"}, result, "open pull request not reused")
+	assert.Len(t, pulls, 1)
+	assert.Equal(t, "vpc, eks", pulls[1]["body"])
+
+	pullRequest.Base = "release"
+	result, err = github.OpenPullRequest(context.Background(), pullRequest)
+	require.NoError(t, err)
+	assert.True(t, result.Created, "pull request into another base reused")
+}
+
+func TestGitHubOpenPullRequestLabelsAndReviewers(t *testing.T) {
+	baseUrl, pulls := newTestGitHub(t, "token")
+	github := NewGitHub(Options{BaseURL: baseUrl, Token: "token", HTTPClient: http.DefaultClient})
+	pullRequest := PullRequest{Repository: "org/infra", Head: "upgrade", Base: "main", Labels: []string{"dependencies", "terraform"}, Reviewers: []string{"octocat"}}
+	_, err := github.OpenPullRequest(context.Background(), pullRequest)
+	require.NoError(t, err)
+	_, err = github.OpenPullRequest(context.Background(), pullRequest)
+	require.NoError(t, err)
+	assert.Equal(t, "dependencies,terraform", pulls[1]["labels"])
+	assert.Equal(t, "octocat", pulls[1]["reviewers"])
+}
+
+func TestGitHubOpenPullRequestFailure(t *testing.T) {
+	baseUrl, pulls := newTestGitHub(t, "token")
+	github := NewGitHub(Options{BaseURL: baseUrl, Token: "expired", HTTPClient: http.DefaultClient})
+	_, err := github.OpenPullRequest(context.Background(), PullRequest{Repository: "org/infra", Head: "upgrade", Base: "main"})
+	assert.ErrorContains(t, err, "401 Unauthorized")
+	assert.ErrorContains(t, err, "Bad credentials")
+	assert.Empty(t, pulls)
+
+	_, err = github.OpenPullRequest(context.Background(), PullRequest{Repository: "infra", Head: "upgrade", Base: "main"})
+	assert.ErrorContains(t, err, "is not <owner>/<name>")
+}
@@ -0,0 +1,90 @@
+package forge
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+const DefaultGitLabURL = "https://gitlab.com/api/v4"
+
+// GitLab opens merge requests through the GitLab REST API, gitlab.com or self-hosted with the /api/v4 base url
+type GitLab struct {
+	options Options
+}
+
+type gitLabMergeRequest struct {
+	IID    int    `json:"iid"`
+	WebURL string `json:"web_url"`
+}
+
+type gitLabUser struct {
+	ID int `json:"id"`
+}
+
+func NewGitLab(options Options) *GitLab {
+	return &GitLab{options: options.withDefaults(DefaultGitLabURL)}
+}
+
+func (g *GitLab) headers() map[string]string {
+	return map[string]string{"Accept": "application/json", "Authorization": g.options.authorization()}
+}
+
+// Ids of the users with the usernames, merge requests are assigned reviewers by id
+func (g *GitLab) getUserIds(ctx context.Context, usernames []string) ([]int, error) {
+	ids := make([]int, 0, len(usernames))
+	for _, username := range usernames {
+		var users []gitLabUser
+		err := doJSON(ctx, g.options.HTTPClient, http.MethodGet, g.options.BaseURL+"/users?"+url.Values{"username": {username}}.Encode(), g.headers(), nil, &users)
+		if err != nil {
+			return nil, err
+		}
+		if len(users) == 0 {
+			return nil, errors.New("gitlab user " + username + " not found")
+		}
+		ids = append(ids, users[0].ID)
+	}
+	return ids, nil
+}
+
+// OpenPullRequest opens the merge request or updates the open one, adding to its labels and replacing its reviewers
+func (g *GitLab) OpenPullRequest(ctx context.Context, pullRequest PullRequest) (PullRequestResult, error) {
+	if !strings.Contains(strings.Trim(pullRequest.Repository, "/"), "/") {
+		return PullRequestResult{}, errors.New("repository " + pullRequest.Repository + " is not <group>/<project>")
+	}
+	mergeRequestsUrl := g.options.BaseURL + "/projects/" + url.PathEscape(strings.Trim(pullRequest.Repository, "/")) + "/merge_requests"
+	query := url.Values{"state": {"opened"}, "source_branch": {pullRequest.Head}, "target_branch": {pullRequest.Base}}
+	var open []gitLabMergeRequest
+	err := doJSON(ctx, g.options.HTTPClient, http.MethodGet, mergeRequestsUrl+"?"+query.Encode(), g.headers(), nil, &open)
+	if err != nil {
+		return PullRequestResult{}, err
+	}
+	content := map[string]any{"title": pullRequest.Title, "description": pullRequest.Body}
+	if len(pullRequest.Reviewers) > 0 {
+		if content["reviewer_ids"], err = g.getUserIds(ctx, pullRequest.Reviewers); err != nil {
+			return PullRequestResult{}, err
+		}
+	}
+	labels := strings.Join(pullRequest.Labels, ",")
+	var opened gitLabMergeRequest
+	if len(open) > 0 {
+		log.Debug().Msgf("gitlab :: OpenPullRequest :: updating :: %s!%d", pullRequest.Repository, open[0].IID)
+		if labels != "" {
+			content["add_labels"] = labels
+		}
+		err = doJSON(ctx, g.options.HTTPClient, http.MethodPut, mergeRequestsUrl+"/"+strconv.Itoa(open[0].IID), g.headers(), content, &opened)
+		return PullRequestResult{Number: opened.IID, URL: opened.WebURL}, err
+	}
+	content["source_branch"], content["target_branch"] = pullRequest.Head, pullRequest.Base
+	if labels != "" {
+		content["labels"] = labels
+	}
+	log.Debug().Msgf("gitlab :: OpenPullRequest :: creating :: %s :: %s -> %s", pullRequest.Repository, pullRequest.Head, pullRequest.Base)
+	err = doJSON(ctx, g.options.HTTPClient, http.MethodPost, mergeRequestsUrl, g.headers(), content, &opened)
+	return PullRequestResult{Number: opened.IID, URL: opened.WebURL, Created: true}, err
+}
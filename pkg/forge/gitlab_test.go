@@ -0,0 +1,89 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Functions to help testing
+
+// Starts a stand-in GitLab API for group/sub/infra with the user octocat keeping the merge requests opened, returns its
+// url and the merge requests
+func newTestGitLab(t *testing.T) (string, map[int]map[string]any) {
+	mergeRequests := make(map[int]map[string]any)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v4/users", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("username") != "octocat" {
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[{"id": 42}]`))
+	})
+	mergeRequestJson := func(iid int) string {
+		return `{"iid": ` + strconv.Itoa(iid) + `, "web_url": "https://gitlab.com/group/sub/infra/-/merge_requests/` + strconv.Itoa(iid) + `"}`
+	}
+	// The project path arrives escaped as a single segment
+	mux.HandleFunc("/api/v4/projects/group%2Fsub%2Finfra/merge_requests", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			for iid, mergeRequest := range mergeRequests {
+				if mergeRequest["source_branch"] == r.URL.Query().Get("source_branch") && mergeRequest["target_branch"] == r.URL.Query().Get("target_branch") {
+					_, _ = w.Write([]byte("[" + mergeRequestJson(iid) + "]"))
+					return
+				}
+			}
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		var mergeRequest map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&mergeRequest))
+		iid := len(mergeRequests) + 1
+		mergeRequests[iid] = mergeRequest
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(mergeRequestJson(iid)))
+	})
+	mux.HandleFunc("PUT /api/v4/projects/group%2Fsub%2Finfra/merge_requests/{iid}", func(w http.ResponseWriter, r *http.Request) {
+		iid, _ := strconv.Atoi(r.PathValue("iid"))
+		var update map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&update))
+		for field, value := range update {
+			mergeRequests[iid][field] = value
+		}
+		_, _ = w.Write([]byte(mergeRequestJson(iid)))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server.URL + "/api/v4", mergeRequests
+}
+
+func TestGitLabOpenPullRequest(t *testing.T) {
+	baseUrl, mergeRequests := newTestGitLab(t)
+	gitlab, err := New(GitLabForge, Options{BaseURL: baseUrl, Token: "token"})
+	require.NoError(t, err)
+	pullRequest := PullRequest{Repository: "group/sub/infra", Head: "upgrade", Base: "main", Title: "Update 1 terraform module(s)", Body: "vpc", Labels: []string{"dependencies", "terraform"}, Reviewers: []string{"octocat"}}
+
+	result, err := gitlab.OpenPullRequest(context.Background(), pullRequest)
+	require.NoError(t, err)
+	assert.Equal(t, PullRequestResult{Number: 1, URL: "https://gitlab.com/group/sub/infra/-/merge_requests/1", Created: true}, result)
+	assert.Equal(t, map[string]any{"title": "Update 1 terraform module(s)", "description": "vpc", "source_branch": "upgrade", "target_branch": "main", "labels": "dependencies,terraform", "reviewer_ids": []any{42.0}}, mergeRequests[1])
+
+	pullRequest.Body = "vpc, eks"
+	result, err = gitlab.OpenPullRequest(context.Background(), pullRequest)
+	require.NoError(t, err)
+	assert.False(t, result.Created, "open merge request not reused")
+	assert.Len(t, mergeRequests, 1)
+	assert.Equal(t, "vpc, eks", mergeRequests[1]["description"])
+	assert.Equal(t, "dependencies,terraform", mergeRequests[1]["add_labels"])
+
+	pullRequest.Reviewers = []string{"ghost"}
+	_, err = gitlab.OpenPullRequest(context.Background(), pullRequest)
+	assert.ErrorContains(t, err, "gitlab user ghost not found")
+	_, err = gitlab.OpenPullRequest(context.Background(), PullRequest{Repository: "infra"})
+	assert.ErrorContains(t, err, "is not <group>/<project>")
+}
@@ -0,0 +1,169 @@
+package resolver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// CacheEntry is the tag listing of a module source cached by a Resolver
+type CacheEntry struct {
+	Url       string    `json:"url"`
+	Tags      []string  `json:"tags"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// Names of the entry files written to the cache directory, anything else in it is left alone
+var cacheEntryNameRegex = regexp.MustCompile(`^[0-9a-f]{64}\.json$`)
+
+// DefaultCacheDir is samwise under the user cache dir
+func DefaultCacheDir() (string, error) {
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(userCacheDir, "samwise"), nil
+}
+
+func getCacheEntryPath(cacheDir string, key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(cacheDir, hex.EncodeToString(hash[:])+".json")
+}
+
+// Paths of the entry files in the cache directory, none when it doesn't exist yet
+func getCacheEntryPaths(cacheDir string) ([]string, error) {
+	if cacheDir == "" {
+		return nil, errors.New("cache directory is not set")
+	}
+	dirEntries, err := os.ReadDir(cacheDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entryPaths []string
+	for _, dirEntry := range dirEntries {
+		if dirEntry.Type().IsRegular() && cacheEntryNameRegex.MatchString(dirEntry.Name()) {
+			entryPaths = append(entryPaths, filepath.Join(cacheDir, dirEntry.Name()))
+		}
+	}
+	return entryPaths, nil
+}
+
+func readCacheEntry(entryPath string) (CacheEntry, error) {
+	var entry CacheEntry
+	content, err := os.ReadFile(entryPath)
+	if err != nil {
+		return entry, err
+	}
+	err = json.Unmarshal(content, &entry)
+	return entry, err
+}
+
+func writeCacheEntry(cacheDir string, entry CacheEntry) error {
+	err := os.MkdirAll(cacheDir, 0o755)
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	// Written to a temporary file first so parallel lookups never read a partial entry
+	tmpFile, err := os.CreateTemp(cacheDir, ".entry-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmpFile.Write(content)
+	closeErr := tmpFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpFile.Name())
+		return err
+	}
+	return os.Rename(tmpFile.Name(), getCacheEntryPath(cacheDir, entry.Url))
+}
+
+// Returns the tags cached for key when they are younger than CacheTTL, otherwise fetches and caches them
+func (r *Resolver) getTagsWithCache(key string, fetch func() ([]string, error)) ([]string, error) {
+	cacheDir := r.options.CacheDir
+	if r.options.NoCache || cacheDir == "" || key == "" {
+		return fetch()
+	}
+	entry, err := readCacheEntry(getCacheEntryPath(cacheDir, key))
+	if err == nil && entry.Url == key && time.Since(entry.FetchedAt) < r.options.CacheTTL {
+		log.Debug().Msgf("cache :: getTagsWithCache :: cache hit :: %s :: fetched_at :: %s", key, entry.FetchedAt.String())
+		return entry.Tags, nil
+	}
+	log.Debug().Msgf("cache :: getTagsWithCache :: cache miss :: %s", key)
+	tags, err := fetch()
+	if err != nil || r.options.ReadOnlyCache {
+		return tags, err
+	}
+	err = writeCacheEntry(cacheDir, CacheEntry{Url: key, Tags: tags, FetchedAt: time.Now()})
+	if err != nil {
+		log.Error().Msgf("cache :: getTagsWithCache :: unable to write cache entry %s :: %s", key, err.Error())
+	}
+	return tags, nil
+}
+
+// WriteCache stores the tags of the module source in the cache directory as just fetched, under the key every spelling
+// of the source is looked up by
+func WriteCache(cacheDir string, sourceType string, repo string, tags []string) error {
+	if cacheDir == "" {
+		return errors.New("cache directory is not set")
+	}
+	return writeCacheEntry(cacheDir, CacheEntry{Url: getModuleSourceKey(sourceType, repo), Tags: tags, FetchedAt: time.Now()})
+}
+
+// ListCache returns every entry in the cache directory sorted by url
+func ListCache(cacheDir string) ([]CacheEntry, error) {
+	entryPaths, err := getCacheEntryPaths(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	var entries []CacheEntry
+	for _, entryPath := range entryPaths {
+		entry, err := readCacheEntry(entryPath)
+		if err != nil {
+			log.Error().Msgf("cache :: ListCache :: unable to read cache entry %s :: %s", entryPath, err.Error())
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return strings.Compare(entries[i].Url, entries[j].Url) < 0
+	})
+	return entries, nil
+}
+
+// ClearCache removes every entry in the cache directory and returns how many were removed. Files the cache didn't write
+// are kept.
+func ClearCache(cacheDir string) (int, error) {
+	entryPaths, err := getCacheEntryPaths(cacheDir)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, entryPath := range entryPaths {
+		err = os.Remove(entryPath)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,129 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thundersparkf/samwise/pkg/scanner"
+)
+
+// Functions to help testing
+
+// Returns a resolver caching in an empty directory for the test
+func useTestCache(t *testing.T) (*Resolver, string) {
+	cacheDir := t.TempDir()
+	return New(Options{CacheDir: cacheDir, CacheTTL: time.Hour}), cacheDir
+}
+
+func TestGetTagsWithCache(t *testing.T) {
+	r, _ := useTestCache(t)
+	fetches := 0
+	fetch := func() ([]string, error) {
+		fetches++
+		return []string{"v1.0.0", "v1.1.0"}, nil
+	}
+
+	tags, err := r.getTagsWithCache("https://example.com/vpc.git", fetch)
+	assert.Empty(t, err)
+	assert.Equal(t, []string{"v1.0.0", "v1.1.0"}, tags)
+	tags, err = r.getTagsWithCache("https://example.com/vpc.git", fetch)
+	assert.Empty(t, err)
+	assert.Equal(t, []string{"v1.0.0", "v1.1.0"}, tags)
+	assert.Equal(t, 1, fetches, "cached tags fetched again")
+
+	_, _ = r.getTagsWithCache("https://example.com/eks.git", fetch)
+	assert.Equal(t, 2, fetches, "tags of a different url served from the cache")
+
+	r.options.NoCache = true
+	_, _ = r.getTagsWithCache("https://example.com/vpc.git", fetch)
+	assert.Equal(t, 3, fetches, "cache used with NoCache")
+
+	r.options.NoCache = false
+	r.options.CacheTTL = 0
+	_, _ = r.getTagsWithCache("https://example.com/vpc.git", fetch)
+	assert.Equal(t, 4, fetches, "expired cache entry used")
+
+	r.options.CacheTTL, r.options.ReadOnlyCache = time.Hour, true
+	_, _ = r.getTagsWithCache("https://example.com/s3.git", fetch)
+	_, _ = r.getTagsWithCache("https://example.com/s3.git", fetch)
+	assert.Equal(t, 6, fetches, "tags cached with ReadOnlyCache")
+	_, _ = r.getTagsWithCache("https://example.com/vpc.git", fetch)
+	assert.Equal(t, 6, fetches, "cached tags not used with ReadOnlyCache")
+}
+
+func TestGetTagsWithCacheFailure(t *testing.T) {
+	r, cacheDir := useTestCache(t)
+	_, err := r.getTagsWithCache("https://example.com/vpc.git", func() ([]string, error) {
+		return nil, errors.New("network error")
+	})
+	assert.NotEmpty(t, err)
+	entries, err := ListCache(cacheDir)
+	assert.Empty(t, err)
+	assert.Empty(t, entries, "failed lookup cached")
+}
+
+func TestListAndClearCache(t *testing.T) {
+	r, cacheDir := useTestCache(t)
+	for _, url := range []string{"https://example.com/vpc.git", "https://example.com/eks.git"} {
+		_, err := r.getTagsWithCache(url, func() ([]string, error) {
+			return []string{"v1.0.0"}, nil
+		})
+		assert.Empty(t, err)
+	}
+	entries, err := ListCache(cacheDir)
+	assert.Empty(t, err)
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, "https://example.com/eks.git", entries[0].Url)
+	assert.Equal(t, []string{"v1.0.0"}, entries[0].Tags)
+	assert.WithinDuration(t, time.Now(), entries[0].FetchedAt, time.Minute)
+
+	require.NoError(t, os.WriteFile(cacheDir+"/package.json", []byte(`{"url": "package"}`), os.ModePerm))
+	removed, err := ClearCache(cacheDir)
+	assert.Empty(t, err)
+	assert.Equal(t, 2, removed)
+	entries, err = ListCache(cacheDir)
+	assert.Empty(t, err)
+	assert.Empty(t, entries)
+	assert.FileExists(t, cacheDir+"/package.json", "file the cache didn't write removed")
+
+	_, err = ClearCache("")
+	assert.EqualError(t, err, "cache directory is not set")
+	_, err = ListCache("")
+	assert.EqualError(t, err, "cache directory is not set")
+}
+
+func TestResolveModuleCache(t *testing.T) {
+	r, cacheDir := useTestCache(t)
+	repoUrl := newTestGitRepo(t, "v1.0.0", "v1.1.0")
+	module := scanner.ModuleUsage{Repo: repoUrl, CurrentVersion: "v1.0.0", SourceType: scanner.GitSourceType}
+	result := r.ResolveModule(context.Background(), module)
+	assert.Empty(t, result.Error)
+	assert.Equal(t, []string{"v1.1.0"}, result.UpdatesAvailable)
+
+	// Served from the cache by a new resolver once the repository is gone
+	require.NoError(t, os.RemoveAll(repoUrl[len("file://"):]))
+	cached := New(Options{CacheDir: cacheDir, CacheTTL: time.Hour}).ResolveModule(context.Background(), module)
+	assert.Empty(t, cached.Error)
+	assert.Equal(t, []string{"v1.1.0"}, cached.UpdatesAvailable)
+}
+
+func TestWriteCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	require.NoError(t, WriteCache(cacheDir, scanner.GitSourceType, "git::https://github.com/org/vpc.git", []string{"v1.0.0", "v1.1.0"}))
+	entries, err := ListCache(cacheDir)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(entries))
+	assert.Equal(t, "github.com/org/vpc", entries[0].Url)
+
+	// Another spelling of the source is served from the entry without a lookup
+	result := New(Options{CacheDir: cacheDir, CacheTTL: time.Hour}).ResolveModule(context.Background(), scanner.ModuleUsage{Repo: "https://github.com/org/vpc", CurrentVersion: "v1.0.0", SourceType: scanner.GitSourceType})
+	assert.Empty(t, result.Error)
+	assert.Equal(t, []string{"v1.1.0"}, result.UpdatesAvailable)
+
+	assert.EqualError(t, WriteCache("", scanner.GitSourceType, "github.com/org/vpc", nil), "cache directory is not set")
+}
@@ -0,0 +1,8 @@
+package resolver
+
+// Prefixes of the errors of module source lookups
+const CloningErrorPrefix = "unable to clone repo "
+const RegistryErrorPrefix = "unable to query registry "
+const ListingTagsErrorPrefix = "unable to list remote tags "
+const VersionParsingErrorPrefix = "unable to parse version "
+const RefResolvingErrorPrefix = "unable to find ref "
@@ -0,0 +1,153 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	sshgit "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/rs/zerolog/log"
+	"github.com/thundersparkf/samwise/pkg/failure"
+	"golang.org/x/crypto/ssh"
+)
+
+// Basic auth from GitUser and GitKey for https urls, the GitSSHKeyPath key for urls with a user
+func (r *Resolver) gitAuthGenerator(url string) (transport.AuthMethod, error) {
+	if !strings.Contains(url, "@") {
+		log.Debug().Msg("using basic https auth")
+		return &http.BasicAuth{
+			Username: r.options.GitUser,
+			Password: r.options.GitKey,
+		}, nil
+	}
+	log.Debug().Msg("using ssh auth")
+	username := strings.Split(url, "@")[0]
+	sshPath := r.options.GitSSHKeyPath
+	log.Debug().Msg("git :: gitAuthGenerator :: " + sshPath)
+	sshKey, err := os.ReadFile(sshPath)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(sshKey)
+	if err != nil {
+		return nil, err
+	}
+	publicKey := &sshgit.PublicKeys{User: username, Signer: signer, HostKeyCallbackHelper: sshgit.HostKeyCallbackHelper{
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}}
+	return publicKey, nil
+}
+
+// GitAuth returns the auth for the git url from the same options module sources are looked up with, for callers pushing
+// to those hosts. Local repositories need none.
+func (r *Resolver) GitAuth(url string) (transport.AuthMethod, error) {
+	if endpoint, err := transport.NewEndpoint(url); err == nil && endpoint.Protocol == "file" {
+		return nil, nil
+	}
+	return r.gitAuthGenerator(url)
+}
+
+func parseGitUrl(source string) string {
+	log.Debug().Msg("git :: parseGitUrl :: source " + source)
+	if strings.Contains(source, "@") || strings.Contains(source, "ssh://") {
+		source = strings.Replace(source, "ssh://", "", 1)
+		return source
+	}
+	source = strings.Replace(source, "git::", "", 1)
+	// Explicit local repositories are kept as is, everything else without a protocol is assumed to be https
+	if strings.HasPrefix(source, "file://") {
+		return source
+	}
+	endpointUrl, err := transport.NewEndpoint(source)
+	if err != nil {
+		log.Error().Msgf("git :: parseGitUrl :: unable to parse git url :: %s", err.Error())
+		return ""
+	}
+	log.Debug().Msgf("git :: parseGitUrl :: endpoint result :: host :: %s :: path :: %s :: protocol :: %s :: ", endpointUrl.Host, endpointUrl.Path, endpointUrl.Protocol)
+
+	if endpointUrl.Protocol == "" || endpointUrl.Protocol == "file" {
+		return "https://" + strings.Replace(endpointUrl.String(), "file://", "", 1)
+	}
+	return endpointUrl.String()
+}
+
+// Auth failure of a git url whose credentials could not be set up
+func getGitAuthFailure(prefix string, err error) error {
+	return failure.NewFailure(failure.StageAuth, failure.ErrorCodeSSHKey, fmt.Errorf(prefix+"%w", err))
+}
+
+// Classifies an error of listing or cloning a remote repository as rejected credentials, a missing repository or an
+// unreachable host
+func getGitRemoteFailure(prefix string, err error) error {
+	stage, code := failure.StageNetwork, failure.ErrorCodeUnreachable
+	if errors.Is(err, transport.ErrAuthenticationRequired) || errors.Is(err, transport.ErrAuthorizationFailed) {
+		stage, code = failure.StageAuth, failure.ErrorCodeAuthFailed
+	} else if errors.Is(err, transport.ErrRepositoryNotFound) {
+		code = failure.ErrorCodeNotFound
+	}
+	return failure.NewFailure(stage, code, fmt.Errorf(prefix+"%w", err))
+}
+
+// Clones the repository into memory, for features that need its history
+func (r *Resolver) cloneRepo(ctx context.Context, source string) (*git.Repository, error) {
+	url := parseGitUrl(source)
+	log.Debug().Msg("git :: cloneRepo :: url :: " + url)
+	if url == "" {
+		log.Debug().Msg("git :: cloneRepo :: url is empty from parseGitUrl")
+		return nil, failure.NewFailure(failure.StageURLNormalize, failure.ErrorCodeInvalidURL, errors.New(CloningErrorPrefix+"invalid url "+source))
+	}
+	authMethod, err := r.gitAuthGenerator(url)
+	if err != nil {
+		return nil, getGitAuthFailure(CloningErrorPrefix, err)
+	}
+	log.Debug().Msgf("git :: cloneRepo :: auth method :: %s", authMethod.String())
+	repo, err := git.CloneContext(ctx, memory.NewStorage(), nil, &git.CloneOptions{
+		URL:  url,
+		Auth: authMethod,
+	})
+	if err != nil {
+		log.Debug().Msg("git :: cloneRepo :: url :: " + url)
+		return nil, getGitRemoteFailure(CloningErrorPrefix, err)
+	}
+	return repo, nil
+}
+
+// Returns the names of the tags of the remote repository by listing its references, without fetching any objects
+func (r *Resolver) listRemoteTags(ctx context.Context, source string) ([]string, error) {
+	url := parseGitUrl(source)
+	log.Debug().Msg("git :: listRemoteTags :: url :: " + url)
+	if url == "" {
+		log.Debug().Msg("git :: listRemoteTags :: url is empty from parseGitUrl")
+		return nil, failure.NewFailure(failure.StageURLNormalize, failure.ErrorCodeInvalidURL, errors.New(ListingTagsErrorPrefix+"invalid url "+source))
+	}
+	authMethod, err := r.gitAuthGenerator(url)
+	if err != nil {
+		return nil, getGitAuthFailure(ListingTagsErrorPrefix, err)
+	}
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: git.DefaultRemoteName,
+		URLs: []string{url},
+	})
+	refs, err := remote.ListContext(ctx, &git.ListOptions{
+		Auth: authMethod,
+	})
+	if err != nil {
+		log.Debug().Msg("git :: listRemoteTags :: url :: " + url)
+		return nil, getGitRemoteFailure(ListingTagsErrorPrefix, err)
+	}
+	var tagNames []string
+	for _, ref := range refs {
+		if ref.Name().IsTag() {
+			tagNames = append(tagNames, ref.Name().Short())
+		}
+	}
+	log.Debug().Msgf("git :: listRemoteTags :: url :: %s :: tags :: %d", url, len(tagNames))
+	return tagNames, nil
+}
@@ -0,0 +1,234 @@
+package resolver
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thundersparkf/samwise/pkg/failure"
+	"github.com/thundersparkf/samwise/pkg/scanner"
+)
+
+// Functions to help testing
+
+// Creates a local repository with one commit per tag and returns its file:// url
+func newTestGitRepo(t *testing.T, tags ...string) string {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+	for _, tag := range tags {
+		err = os.WriteFile(dir+"/main.tf", []byte("# "+tag+"\n"), os.ModePerm)
+		require.NoError(t, err)
+		_, err = w.Add("main.tf")
+		require.NoError(t, err)
+		commit, err := w.Commit("release "+tag, &git.CommitOptions{Author: &object.Signature{Name: "samwise", When: time.Now()}})
+		require.NoError(t, err)
+		_, err = repo.CreateTag(tag, commit, nil)
+		require.NoError(t, err)
+	}
+	return "file://" + dir
+}
+
+func TestGitAuthGenerator(t *testing.T) {
+	basicAuth, err := New(Options{GitUser: "samwise", GitKey: "token"}).gitAuthGenerator("https://github.com/Darth-Tech/terraform-modules")
+	require.NoError(t, err)
+	assert.Equal(t, "http-basic-auth", basicAuth.Name())
+
+	_, err = New(Options{GitSSHKeyPath: t.TempDir() + "/missing"}).gitAuthGenerator("git@github.com:Darth-Tech/stack.git")
+	assert.ErrorIs(t, err, os.ErrNotExist, "missing ssh key not returned as an error")
+
+	keyPath := t.TempDir() + "/id_rsa"
+	require.NoError(t, os.WriteFile(keyPath, []byte("not a key"), 0o600))
+	_, err = New(Options{GitSSHKeyPath: keyPath}).gitAuthGenerator("git@github.com:Darth-Tech/stack.git")
+	assert.NotEmpty(t, err, "malformed ssh key not returned as an error")
+}
+
+func TestGitAuth(t *testing.T) {
+	resolver := New(Options{GitUser: "samwise", GitKey: "token"})
+	for _, url := range []string{"/tmp/remote.git", "file:///tmp/remote.git"} {
+		auth, err := resolver.GitAuth(url)
+		require.NoError(t, err)
+		assert.Nil(t, auth, "auth set for local repository "+url)
+	}
+	auth, err := resolver.GitAuth("https://github.com/Darth-Tech/terraform-modules")
+	require.NoError(t, err)
+	assert.Equal(t, "http-basic-auth", auth.Name())
+}
+
+func TestResolveModuleMissingSSHKey(t *testing.T) {
+	resolver := New(Options{NoCache: true, GitSSHKeyPath: t.TempDir() + "/missing"})
+	result := resolver.ResolveModule(context.Background(), scanner.ModuleUsage{Repo: "git@github.com:Darth-Tech/stack.git", CurrentVersion: "v1.0.0", SourceType: scanner.GitSourceType})
+	assert.Contains(t, result.Error, ListingTagsErrorPrefix)
+	assert.Empty(t, result.UpdatesAvailable)
+	assert.Equal(t, failure.StageAuth, result.ErrorStage)
+	assert.Equal(t, failure.ErrorCodeSSHKey, result.ErrorCode)
+}
+
+func TestParseGitUrl(t *testing.T) {
+	sshAuthProtocol := parseGitUrl("ssh://git@github.com:Darth-Tech/stack.git")
+	assert.NotEmpty(t, sshAuthProtocol, "ssh auth protocol parsing empty")
+	assert.Equal(t, "git@github.com:Darth-Tech/stack.git", sshAuthProtocol, "ssh auth protocol parsing")
+
+	sshNoProtocolAuthProtocol := parseGitUrl("git@github.com:Darth-Tech/stack.git")
+	assert.NotEmpty(t, sshNoProtocolAuthProtocol, "ssh auth without protocol parsing empty")
+	assert.Equal(t, "git@github.com:Darth-Tech/stack.git", sshNoProtocolAuthProtocol, "ssh auth without protocol parsing not matching")
+
+	httpsGithubAuth := parseGitUrl("https://github.com/Darth-Tech/terraform-modules")
+	assert.NotEmpty(t, httpsGithubAuth, "basic auth with https protocol empty url")
+	assert.Equal(t, "https://github.com/Darth-Tech/terraform-modules", httpsGithubAuth, "basic auth with https protocol not matching")
+
+	httpsGithubAuthTag := parseGitUrl("github.com/Darth-Tech/terraform-modules")
+	assert.NotEmpty(t, httpsGithubAuthTag, "basic auth without protocol empty url")
+	assert.Equal(t, "https://github.com/Darth-Tech/terraform-modules", httpsGithubAuthTag, "basic auth without protocol url not matching")
+
+	bitbucketAuth := parseGitUrl("https://bitbucket.org.com/Darth-Tech/terraform-modules")
+	assert.NotEmpty(t, bitbucketAuth, "basic auth with https bitbucket protocol empty url")
+	assert.Equal(t, "https://bitbucket.org.com/Darth-Tech/terraform-modules", bitbucketAuth, "basic auth with https bitbucket protocol not matching")
+
+	randomDomain := parseGitUrl("https://example.com/Darth-Tech/terraform-modules")
+	assert.NotEmpty(t, randomDomain, "basic auth with https random domain protocol empty url")
+	assert.Equal(t, "https://example.com/Darth-Tech/terraform-modules", randomDomain, "basic auth with https random domain protocol not matching")
+
+	localRepo := parseGitUrl("git::file:///tmp/terraform-modules")
+	assert.Equal(t, "file:///tmp/terraform-modules", localRepo, "local file repository not kept as is")
+
+	httpsGithubAuthGitProtovcol := parseGitUrl("git::https://github.com/Darth-Tech/terraform-modules")
+	assert.NotEmpty(t, httpsGithubAuthGitProtovcol, "basic auth with https and git:: random domain protocol empty url")
+	assert.Equal(t, "https://github.com/Darth-Tech/terraform-modules", httpsGithubAuthGitProtovcol, "basic auth with https and git:: random domain protocol not matching")
+}
+
+// TODO: Add tests for ssh cloning
+func TestHappyClonePublicRepo(t *testing.T) {
+	r, err := New(Options{}).cloneRepo(context.Background(), "https://github.com/thundersparkf/adlnet-lrs-py3.git")
+	assert.NotEmpty(t, r, "readGit_files.go :: cloneRepo :: repository is nil")
+	assert.Empty(t, err, "readGit_files.go :: cloneRepo :: err is not nil :: ")
+	head, _ := r.Head()
+	assert.Equal(t, "refs/heads/master", head.Name().String(), "readGit_files.go :: cloneRepo :: repository's head is ref/heads/master")
+
+}
+
+func TestHappySSHClonePublicRepo(t *testing.T) {
+	resolver := New(Options{GitSSHKeyPath: os.Getenv("SAMWISE_CLI_GIT_SSH_KEY_PATH")})
+	r, err := resolver.cloneRepo(context.Background(), "git@github.com:Darth-Tech/stack.git")
+	assert.NotEmpty(t, r, "readGit_files.go :: cloneRepo :: repository is nil")
+	assert.Empty(t, err, "readGit_files.go :: cloneRepo :: err is not nil :: ")
+
+	r1, err := resolver.cloneRepo(context.Background(), "ssh://git@github.com:Darth-Tech/stack.git")
+	assert.NotEmpty(t, r1, "readGit_files.go :: cloneRepo :: repository is nil")
+	assert.Empty(t, err, "readGit_files.go :: cloneRepo :: err is not nil :: ")
+
+	head, err := r.Head()
+	assert.Empty(t, err, "readGit_files.go :: cloneRepo :: error not empty getting head of branch :: error :: ")
+	assert.Equal(t, "refs/heads/master", head.Name().String(), "readGit_files.go :: cloneRepo :: repository's head is ref/heads/master")
+
+	head1, err := r1.Head()
+	assert.Empty(t, err, "readGit_files.go :: cloneRepo :: error not empty getting head of branch :: error :: ")
+	assert.Equal(t, "refs/heads/master", head1.Name().String(), "readGit_files.go :: cloneRepo :: repository's head is ref/heads/master")
+
+}
+
+func TestUnhappyNoProtocolClonePublicRepo(t *testing.T) {
+	r, err := New(Options{}).cloneRepo(context.Background(), "github.com/thundersparkf/adlnet-lrs-py3.git")
+	assert.NotEmpty(t, r, "readGit_files.go :: cloneRepo :: repository is nil")
+	assert.Empty(t, err, "readGit_files.go :: cloneRepo :: err is not nil :: ")
+	head, err := r.Head()
+	assert.Empty(t, err, "readGit_files.go :: cloneRepo :: err is not nil :: ")
+	assert.Equal(t, "refs/heads/master", head.Name().String(), "readGit_files.go :: cloneRepo :: repository's head is ref/heads/master")
+
+}
+
+func TestUnhappyClonePublicRepo(t *testing.T) {
+	r, err := New(Options{}).cloneRepo(context.Background(), "https://github.com/thundersparkf/adlnet-lrs-random-incorrect-url.git")
+	assert.NotEmpty(t, err, "readGit_files.go :: cloneRepo :: err is nil")
+	assert.Empty(t, r, "readGit_files.go :: cloneRepo :: repository is not nil")
+	assert.Contains(t, err.Error(), CloningErrorPrefix)
+
+	empty, err := New(Options{}).cloneRepo(context.Background(), "")
+	assert.Empty(t, empty, "readGit_files.go :: cloneRepo :: repository is not nil")
+	assert.Contains(t, err.Error(), CloningErrorPrefix)
+
+}
+
+func TestResolveModuleGit(t *testing.T) {
+	resolver := New(Options{NoCache: true})
+	module := scanner.ModuleUsage{Repo: "https://github.com/Darth-Tech/terraform-modules.git", CurrentVersion: "v1.0.2", SourceType: scanner.GitSourceType}
+	result := resolver.ResolveModule(context.Background(), module)
+	assert.Empty(t, result.Error, "resolver :: ResolveModule :: error is not empty")
+	assert.Equal(t, []string{"v1.0.3-beta"}, result.UpdatesAvailable)
+
+	module.CurrentVersion = "v1.0.1"
+	multipleTags := resolver.ResolveModule(context.Background(), module)
+	assert.Empty(t, multipleTags.Error, "resolver :: ResolveModule :: error is not empty")
+	assert.Contains(t, multipleTags.UpdatesAvailable, "v1.0.2")
+	assert.Contains(t, multipleTags.UpdatesAvailable, "v1.0.3-beta")
+
+	empty := resolver.ResolveModule(context.Background(), scanner.ModuleUsage{CurrentVersion: "v1.0.1", SourceType: scanner.GitSourceType})
+	assert.Empty(t, empty.UpdatesAvailable)
+	assert.NotEmpty(t, empty.Error)
+}
+
+func TestListRemoteTags(t *testing.T) {
+	repoUrl := newTestGitRepo(t, "v1.0.0", "v1.1.0", "v2.0.0")
+	resolver := New(Options{})
+	tags, err := resolver.listRemoteTags(context.Background(), repoUrl)
+	assert.Empty(t, err, "readGitFiles :: listRemoteTags :: error is not empty")
+	assert.ElementsMatch(t, []string{"v1.0.0", "v1.1.0", "v2.0.0"}, tags)
+
+	empty, err := resolver.listRemoteTags(context.Background(), "")
+	assert.Empty(t, empty)
+	assert.Contains(t, err.Error(), ListingTagsErrorPrefix)
+
+	missing, err := resolver.listRemoteTags(context.Background(), "file://"+t.TempDir()+"/missing")
+	assert.Empty(t, missing)
+	assert.Contains(t, err.Error(), ListingTagsErrorPrefix)
+	stage, code := failure.FailureOf(err)
+	assert.Equal(t, failure.StageNetwork, stage)
+	assert.Equal(t, failure.ErrorCodeNotFound, code)
+}
+
+func TestResolveModuleGitLocal(t *testing.T) {
+	resolver := New(Options{NoCache: true})
+	repoUrl := newTestGitRepo(t, "v1.0.0", "v1.1.0", "v2.0.0-beta", "release")
+	result := resolver.ResolveModule(context.Background(), scanner.ModuleUsage{Repo: repoUrl, CurrentVersion: "v1.0.0", SourceType: scanner.GitSourceType})
+	assert.Empty(t, result.Error, "resolver :: ResolveModule :: error is not empty")
+	assert.Contains(t, result.UpdatesAvailable, "v1.1.0")
+	assert.Contains(t, result.UpdatesAvailable, "v2.0.0-beta")
+	assert.NotContains(t, result.UpdatesAvailable, "v1.0.0")
+	assert.Equal(t, "v2.0.0-beta", result.LatestVersion)
+	assert.Equal(t, SeverityMajor, result.UpgradeSeverity)
+
+	noUpdates := resolver.ResolveModule(context.Background(), scanner.ModuleUsage{Repo: repoUrl, CurrentVersion: "v2.0.0", SourceType: scanner.GitSourceType})
+	assert.Empty(t, noUpdates.Error, "resolver :: ResolveModule :: error is not empty")
+	assert.Empty(t, noUpdates.UpdatesAvailable)
+	assert.False(t, noUpdates.HasUpdates())
+
+	tag := resolver.ResolveModule(context.Background(), scanner.ModuleUsage{Repo: repoUrl, CurrentVersion: "release", SourceType: scanner.GitSourceType})
+	assert.Equal(t, scanner.PinningTag, tag.Pinning)
+	assert.Contains(t, tag.Error, VersionParsingErrorPrefix)
+	assert.Equal(t, failure.StageTagParse, tag.ErrorStage)
+	assert.Equal(t, failure.ErrorCodeInvalidVersion, tag.ErrorCode)
+}
+
+func TestResolveModuleUnpinned(t *testing.T) {
+	resolver := New(Options{NoCache: true})
+	repoUrl, commits := newTestGitHistory(t, "v1.0.0", "v1.1.0", "")
+	for ref, pinning := range map[string]string{"develop": scanner.PinningBranch, commits[0].String()[:7]: scanner.PinningCommit, "": scanner.PinningNone} {
+		result := resolver.ResolveModule(context.Background(), scanner.ModuleUsage{Repo: repoUrl, CurrentVersion: ref, Pinning: scanner.GetPinning(ref), SourceType: scanner.GitSourceType})
+		assert.Equal(t, pinning, result.Pinning, ref)
+		assert.True(t, result.IsUnpinned(), ref)
+		assert.Empty(t, result.Error, ref)
+		assert.Empty(t, result.UpdatesAvailable, ref)
+		assert.Equal(t, "v1.1.0", result.LatestVersion, "latest version to pin to not set for "+ref)
+	}
+
+	missing := resolver.ResolveModule(context.Background(), scanner.ModuleUsage{Repo: "file://" + t.TempDir() + "/missing", CurrentVersion: "main", Pinning: scanner.PinningUnknown, SourceType: scanner.GitSourceType})
+	assert.NotEmpty(t, missing.Error)
+	assert.Equal(t, scanner.PinningUnknown, missing.Pinning, "ref of a source that couldn't be listed classified")
+}
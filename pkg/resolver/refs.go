@@ -0,0 +1,159 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rs/zerolog/log"
+	"github.com/thundersparkf/samwise/pkg/failure"
+	"github.com/thundersparkf/samwise/pkg/scanner"
+)
+
+// RefInfo is where the commit or branch a module block is pinned to stands in the history of its repository
+type RefInfo struct {
+	// Commit the ref points to, the tip for branches
+	Commit string `json:"commit"`
+	// Branch the HEAD of the repository points to, the ref is compared with it
+	DefaultBranch string `json:"default_branch"`
+	// Oldest version tag containing the commit, empty when it hasn't been released yet
+	ContainingTag string `json:"containing_tag,omitempty"`
+	// Newest version tag the commit contains
+	NearestTag string `json:"nearest_tag,omitempty"`
+	// Commits and version tags of the default branch the commit doesn't contain
+	CommitsBehind int `json:"commits_behind"`
+	TagsBehind    int `json:"tags_behind"`
+}
+
+// Clones the repository at most once per resolver. Listing the tags doesn't need the history so this is only done for
+// sources used with a commit or branch ref.
+func (r *Resolver) getGitRepo(ctx context.Context, url string) (*git.Repository, error) {
+	key := getModuleSourceKey(scanner.GitSourceType, url)
+	r.mu.Lock()
+	lookup, ok := r.clones[key]
+	if !ok {
+		lookup = &cloneLookup{}
+		r.clones[key] = lookup
+	}
+	r.mu.Unlock()
+	lookup.once.Do(func() {
+		log.Debug().Msgf("refs :: getGitRepo :: cloning :: %s", key)
+		lookup.repo, lookup.err = r.cloneRepo(ctx, url)
+	})
+	return lookup.repo, lookup.err
+}
+
+// Resolves a commit sha, short ones included, or a branch of the remote to its commit
+func resolveRefCommit(repo *git.Repository, ref string, pinning string) (*object.Commit, error) {
+	revision := plumbing.Revision(ref)
+	if pinning == scanner.PinningBranch {
+		revision = plumbing.Revision(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, ref))
+	}
+	hash, err := repo.ResolveRevision(revision)
+	if err != nil {
+		return nil, err
+	}
+	return repo.CommitObject(*hash)
+}
+
+// Hashes of the commits reachable from hash, hash included
+func getReachableCommits(repo *git.Repository, hash plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	commits, err := repo.Log(&git.LogOptions{From: hash})
+	if err != nil {
+		return nil, err
+	}
+	reachable := make(map[plumbing.Hash]bool)
+	err = commits.ForEach(func(commit *object.Commit) error {
+		reachable[commit.Hash] = true
+		return nil
+	})
+	return reachable, err
+}
+
+// Works out where the ref stands against the version tags and the default branch of the repository, the versions are
+// read off the tags with the rule when there is one
+func getRefInfo(repo *git.Repository, ref string, pinning string, rule *TagRule) (*RefInfo, error) {
+	commit, err := resolveRefCommit(repo, ref, pinning)
+	if err != nil {
+		return nil, err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, err
+	}
+	reachable, err := getReachableCommits(repo, commit.Hash)
+	if err != nil {
+		return nil, err
+	}
+	reachableFromHead, err := getReachableCommits(repo, head.Hash())
+	if err != nil {
+		return nil, err
+	}
+	refInfo := &RefInfo{Commit: commit.Hash.String(), DefaultBranch: head.Name().Short()}
+	for hash := range reachableFromHead {
+		if !reachable[hash] {
+			refInfo.CommitsBehind++
+		}
+	}
+
+	tags, err := repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+	var nearestVersion, containingVersion string
+	err = tags.ForEach(func(tag *plumbing.Reference) error {
+		tagName := tag.Name().Short()
+		tagVersion, ok := rule.Version(tagName)
+		if !ok {
+			return nil
+		}
+		// Annotated tags are peeled to the commit they point to
+		tagHash, err := repo.ResolveRevision(plumbing.Revision(tag.Name()))
+		if err != nil {
+			return err
+		}
+		if reachable[*tagHash] && (nearestVersion == "" || IsNewer(nearestVersion, tagVersion)) {
+			refInfo.NearestTag, nearestVersion = tagName, tagVersion
+		}
+		if reachableFromHead[*tagHash] && !reachable[*tagHash] {
+			refInfo.TagsBehind++
+		}
+		// Tags reachable from the commit are older than it, apart from the ones on the commit itself
+		containsCommit := *tagHash == commit.Hash
+		if !reachable[*tagHash] {
+			tagCommit, err := repo.CommitObject(*tagHash)
+			if err != nil {
+				return err
+			}
+			if containsCommit, err = commit.IsAncestor(tagCommit); err != nil {
+				return err
+			}
+		}
+		if containsCommit && (containingVersion == "" || IsNewer(tagVersion, containingVersion)) {
+			refInfo.ContainingTag, containingVersion = tagName, tagVersion
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Debug().Msgf("refs :: getRefInfo :: ref :: %s :: commit :: %s :: containing :: %s :: nearest :: %s :: behind :: %d commits :: %d tags", ref, refInfo.Commit, refInfo.ContainingTag, refInfo.NearestTag, refInfo.CommitsBehind, refInfo.TagsBehind)
+	return refInfo, nil
+}
+
+// Sets where the commit or branch of the module block stands in the history of its source
+func (r *Resolver) getRefUpdateResult(ctx context.Context, updateResult UpdateResult, rule *TagRule) UpdateResult {
+	repo, err := r.getGitRepo(ctx, updateResult.Repo)
+	if err != nil {
+		return NewFailedResult(updateResult.ModuleUsage, err)
+	}
+	refInfo, err := getRefInfo(repo, updateResult.CurrentVersion, updateResult.Pinning, rule)
+	if err != nil {
+		err = fmt.Errorf(RefResolvingErrorPrefix+updateResult.CurrentVersion+" %w", err)
+		return NewFailedResult(updateResult.ModuleUsage, failure.NewFailure(failure.StageTagParse, failure.ErrorCodeUnknownRef, err))
+	}
+	updateResult.Ref = refInfo
+	return updateResult
+}
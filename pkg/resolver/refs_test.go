@@ -0,0 +1,136 @@
+package resolver
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thundersparkf/samwise/pkg/failure"
+	"github.com/thundersparkf/samwise/pkg/scanner"
+)
+
+// Creates a local repository with a commit per entry, tagged unless the entry is empty, and returns its file:// url
+// along with the commit hashes
+func newTestGitHistory(t *testing.T, tags ...string) (string, []plumbing.Hash) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+	var commits []plumbing.Hash
+	for i, tag := range tags {
+		require.NoError(t, os.WriteFile(dir+"/main.tf", []byte("# "+string(rune('a'+i))+"\n"), os.ModePerm))
+		_, err = w.Add("main.tf")
+		require.NoError(t, err)
+		commit, err := w.Commit("commit "+tag, &git.CommitOptions{Author: &object.Signature{Name: "samwise", When: time.Now()}})
+		require.NoError(t, err)
+		commits = append(commits, commit)
+		if tag != "" {
+			_, err = repo.CreateTag(tag, commit, nil)
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("develop"), commits[2])))
+	return "file://" + dir, commits
+}
+
+func TestGetRefInfo(t *testing.T) {
+	repoUrl, commits := newTestGitHistory(t, "v1.0.0", "v1.1.0", "", "v1.2.0", "")
+	repo, err := New(Options{}).cloneRepo(context.Background(), repoUrl)
+	require.NoError(t, err)
+
+	unreleased := &RefInfo{Commit: commits[2].String(), DefaultBranch: "master", ContainingTag: "v1.2.0", NearestTag: "v1.1.0", CommitsBehind: 2, TagsBehind: 1}
+	refInfo, err := getRefInfo(repo, commits[2].String()[:7], scanner.PinningCommit, nil)
+	require.NoError(t, err)
+	assert.Equal(t, unreleased, refInfo, "short sha not resolved")
+	refInfo, err = getRefInfo(repo, "develop", scanner.PinningBranch, nil)
+	require.NoError(t, err)
+	assert.Equal(t, unreleased, refInfo, "branch tip not resolved")
+
+	refInfo, err = getRefInfo(repo, commits[0].String(), scanner.PinningCommit, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &RefInfo{Commit: commits[0].String(), DefaultBranch: "master", ContainingTag: "v1.0.0", NearestTag: "v1.0.0", CommitsBehind: 4, TagsBehind: 2}, refInfo)
+
+	refInfo, err = getRefInfo(repo, "master", scanner.PinningBranch, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &RefInfo{Commit: commits[4].String(), DefaultBranch: "master", NearestTag: "v1.2.0"}, refInfo, "default branch behind itself")
+
+	_, err = getRefInfo(repo, "deadbee", scanner.PinningCommit, nil)
+	assert.NotEmpty(t, err, "missing commit resolved")
+	_, err = getRefInfo(repo, "feature", scanner.PinningBranch, nil)
+	assert.NotEmpty(t, err, "missing branch resolved")
+}
+
+func TestResolveModuleRefs(t *testing.T) {
+	resolver := New(Options{NoCache: true})
+	repoUrl, commits := newTestGitHistory(t, "v1.0.0", "v1.1.0", "", "v1.2.0")
+	commit := resolver.ResolveModule(context.Background(), scanner.ModuleUsage{Repo: repoUrl, CurrentVersion: commits[2].String(), Pinning: scanner.PinningCommit, SourceType: scanner.GitSourceType})
+	assert.Empty(t, commit.Error)
+	assert.Equal(t, "v1.2.0", commit.LatestVersion)
+	require.NotNil(t, commit.Ref)
+	assert.Equal(t, "v1.2.0", commit.Ref.ContainingTag)
+
+	branch := resolver.ResolveModule(context.Background(), scanner.ModuleUsage{Repo: repoUrl, CurrentVersion: "develop", Pinning: scanner.PinningUnknown, SourceType: scanner.GitSourceType})
+	assert.Empty(t, branch.Error)
+	assert.Equal(t, scanner.PinningBranch, branch.Pinning)
+	require.NotNil(t, branch.Ref)
+	assert.Equal(t, commits[2].String(), branch.Ref.Commit)
+	assert.Equal(t, 1, len(resolver.clones), "source cloned more than once")
+
+	missing := resolver.ResolveModule(context.Background(), scanner.ModuleUsage{Repo: repoUrl, CurrentVersion: "feature", Pinning: scanner.PinningUnknown, SourceType: scanner.GitSourceType})
+	assert.Contains(t, missing.Error, RefResolvingErrorPrefix+"feature")
+	assert.Equal(t, failure.StageTagParse, missing.ErrorStage)
+	assert.Equal(t, failure.ErrorCodeUnknownRef, missing.ErrorCode)
+	assert.Nil(t, missing.Ref)
+
+	version := resolver.ResolveModule(context.Background(), scanner.ModuleUsage{Repo: repoUrl, CurrentVersion: "v1.0.0", Pinning: scanner.PinningVersion, SourceType: scanner.GitSourceType})
+	assert.Nil(t, version.Ref, "history looked up for a version")
+}
+
+func TestResolveModuleVersions(t *testing.T) {
+	resolver := New(Options{NoCache: true})
+	repoUrl, commits := newTestGitHistory(t, "v1.0.0", "v1.1.0", "", "v1.2.0")
+	for _, module := range []scanner.ModuleUsage{
+		{Repo: repoUrl, CurrentVersion: commits[2].String(), Pinning: scanner.PinningCommit, SourceType: scanner.GitSourceType},
+		{Repo: repoUrl, CurrentVersion: "develop", Pinning: scanner.PinningUnknown, SourceType: scanner.GitSourceType},
+	} {
+		result := resolver.ResolveModuleVersions(context.Background(), module)
+		assert.Empty(t, result.Error)
+		assert.Nil(t, result.Ref, "history resolved for "+module.CurrentVersion)
+	}
+	assert.Empty(t, resolver.clones, "source cloned")
+}
+
+func TestResolveRefsInWorkers(t *testing.T) {
+	var modules []scanner.ModuleUsage
+	for range 3 {
+		repoUrl, commits := newTestGitHistory(t, "v1.0.0", "", "v1.1.0")
+		modules = append(modules,
+			scanner.ModuleUsage{Repo: repoUrl, CurrentVersion: commits[1].String(), Pinning: scanner.PinningCommit, SourceType: scanner.GitSourceType},
+			scanner.ModuleUsage{Repo: repoUrl, CurrentVersion: "develop", Pinning: scanner.PinningBranch, SourceType: scanner.GitSourceType})
+	}
+	var resolver *Resolver
+	clonedBeforeDone := 0
+	resolver = New(Options{Concurrency: 3, NoCache: true, Progress: func(done int, total int) {
+		if done == total {
+			resolver.mu.Lock()
+			clonedBeforeDone = len(resolver.clones)
+			resolver.mu.Unlock()
+		}
+	}})
+	results, failureList, err := resolver.Resolve(context.Background(), modules)
+	require.NoError(t, err)
+	assert.Empty(t, failureList)
+	assert.Equal(t, 3, clonedBeforeDone, "sources cloned after the workers finished")
+	assert.Equal(t, 3, len(resolver.clones), "source cloned more than once")
+	for _, result := range results {
+		require.NotNil(t, result.Ref)
+		assert.Equal(t, "v1.1.0", result.Ref.ContainingTag)
+	}
+}
@@ -0,0 +1,119 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/thundersparkf/samwise/pkg/failure"
+)
+
+type registryDiscovery struct {
+	ModulesV1 string `json:"modules.v1"`
+}
+
+type registryModuleVersions struct {
+	Modules []struct {
+		Versions []struct {
+			Version string `json:"version"`
+		} `json:"versions"`
+	} `json:"modules"`
+}
+
+// Stage and code of a registry response status other than 200
+func getRegistryStatusFailure(requestUrl string, response *http.Response) error {
+	err := errors.New(requestUrl + " returned " + response.Status)
+	switch response.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return failure.NewFailure(failure.StageAuth, failure.ErrorCodeAuthFailed, err)
+	case http.StatusNotFound:
+		return failure.NewFailure(failure.StageNetwork, failure.ErrorCodeNotFound, err)
+	}
+	return failure.NewFailure(failure.StageNetwork, failure.ErrorCodeRegistry, err)
+}
+
+func (r *Resolver) registryGet(ctx context.Context, requestUrl string, target any) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, requestUrl, nil)
+	if err != nil {
+		return failure.NewFailure(failure.StageURLNormalize, failure.ErrorCodeInvalidURL, err)
+	}
+	request.Header.Set("Accept", "application/json")
+	if token := r.options.RegistryToken; token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	response, err := r.options.HTTPClient.Do(request)
+	if err != nil {
+		return failure.NewFailure(failure.StageNetwork, failure.ErrorCodeUnreachable, err)
+	}
+	defer func() {
+		if err := response.Body.Close(); err != nil {
+			log.Error().Msgf("registry :: registryGet :: unable to close response body :: %s", err.Error())
+		}
+	}()
+	if response.StatusCode != http.StatusOK {
+		return getRegistryStatusFailure(requestUrl, response)
+	}
+	if err = json.NewDecoder(response.Body).Decode(target); err != nil {
+		return failure.NewFailure(failure.StageTagParse, failure.ErrorCodeInvalidResponse, fmt.Errorf("%s :: %w", requestUrl, err))
+	}
+	return nil
+}
+
+// Resolves the modules.v1 endpoint of a registry host at most once per resolver
+func (r *Resolver) discoverRegistryModulesUrl(ctx context.Context, host string) (string, error) {
+	r.mu.Lock()
+	lookup, ok := r.discoveries[host]
+	if !ok {
+		lookup = &discoveryLookup{}
+		r.discoveries[host] = lookup
+	}
+	r.mu.Unlock()
+	lookup.once.Do(func() {
+		lookup.modulesUrl, lookup.err = r.getRegistryModulesUrl(ctx, host)
+	})
+	return lookup.modulesUrl, lookup.err
+}
+
+// Reads the modules.v1 endpoint of a registry host off its service discovery document
+func (r *Resolver) getRegistryModulesUrl(ctx context.Context, host string) (string, error) {
+	baseUrl := "https://" + host
+	var discovery registryDiscovery
+	err := r.registryGet(ctx, baseUrl+"/.well-known/terraform.json", &discovery)
+	if err != nil {
+		return "", err
+	}
+	if discovery.ModulesV1 == "" {
+		return "", failure.NewFailure(failure.StageNetwork, failure.ErrorCodeRegistry, errors.New(host+" does not provide modules.v1"))
+	}
+	base, _ := url.Parse(baseUrl + "/")
+	modulesUrl, err := base.Parse(discovery.ModulesV1)
+	if err != nil {
+		return "", failure.NewFailure(failure.StageURLNormalize, failure.ErrorCodeInvalidURL, err)
+	}
+	log.Debug().Msgf("registry :: getRegistryModulesUrl :: host :: %s :: modules.v1 :: %s", host, modulesUrl.String())
+	return strings.TrimRight(modulesUrl.String(), "/"), nil
+}
+
+func (r *Resolver) getRegistryVersions(ctx context.Context, host string, moduleAddress string) ([]string, error) {
+	modulesUrl, err := r.discoverRegistryModulesUrl(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	var moduleVersions registryModuleVersions
+	err = r.registryGet(ctx, modulesUrl+"/"+moduleAddress+"/versions", &moduleVersions)
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, module := range moduleVersions.Modules {
+		for _, moduleVersion := range module.Versions {
+			versions = append(versions, moduleVersion.Version)
+		}
+	}
+	return versions, nil
+}
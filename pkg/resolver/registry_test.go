@@ -0,0 +1,163 @@
+package resolver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thundersparkf/samwise/pkg/failure"
+	"github.com/thundersparkf/samwise/pkg/scanner"
+)
+
+// Functions to help testing
+
+// Starts a stand-in registry serving versions for the given module addresses, returns its host and a client trusting it
+func newTestRegistry(t *testing.T, modules map[string][]string) (string, *http.Client) {
+	host, client, _ := newTestRegistryWithDiscoveries(t, modules)
+	return host, client
+}
+
+// newTestRegistry also returning the number of discovery documents served
+func newTestRegistryWithDiscoveries(t *testing.T, modules map[string][]string) (string, *http.Client, *atomic.Int32) {
+	discoveries := &atomic.Int32{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/terraform.json", func(w http.ResponseWriter, r *http.Request) {
+		discoveries.Add(1)
+		_, _ = w.Write([]byte(`{"modules.v1": "/v1/modules/"}`))
+	})
+	mux.HandleFunc("/v1/modules/", func(w http.ResponseWriter, r *http.Request) {
+		moduleAddress := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/modules/"), "/versions")
+		versions, ok := modules[moduleAddress]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		var versionsJson []string
+		for _, moduleVersion := range versions {
+			versionsJson = append(versionsJson, `{"version": "`+moduleVersion+`"}`)
+		}
+		_, _ = w.Write([]byte(`{"modules": [{"source": "` + moduleAddress + `", "versions": [` + strings.Join(versionsJson, ",") + `]}]}`))
+	})
+	server := httptest.NewTLSServer(mux)
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "https://"), server.Client(), discoveries
+}
+
+func TestResolveModuleRegistry(t *testing.T) {
+	host, client := newTestRegistry(t, map[string][]string{"example-corp/vpc/aws": {"1.0.0", "1.1.0", "2.0.0"}})
+	resolver := New(Options{HTTPClient: client})
+	module := scanner.ModuleUsage{Repo: host + "/example-corp/vpc/aws", CurrentVersion: "1.0.0", SourceType: scanner.RegistrySourceType}
+
+	result := resolver.ResolveModule(context.Background(), module)
+	assert.Empty(t, result.Error, "resolver :: ResolveModule :: error is not empty")
+	assert.Equal(t, []string{"1.1.0", "2.0.0"}, result.UpdatesAvailable)
+	assert.Equal(t, "2.0.0", result.LatestVersion)
+	assert.Equal(t, SeverityMajor, result.UpgradeSeverity)
+	assert.True(t, result.IsMajorUpgrade)
+	assert.Empty(t, result.LatestMatchingVersion, "latest matching version set for an exact version")
+	assert.Nil(t, result.ConstraintExcludesLatest, "constraint fields set for an exact version")
+
+	module.CurrentVersion = "2.0.0"
+	noUpdates := resolver.ResolveModule(context.Background(), module)
+	assert.Empty(t, noUpdates.Error, "resolver :: ResolveModule :: error is not empty")
+	assert.Empty(t, noUpdates.UpdatesAvailable)
+
+	module.Repo = host + "/example-corp/missing/aws"
+	missing := resolver.ResolveModule(context.Background(), module)
+	assert.Empty(t, missing.UpdatesAvailable)
+	assert.Contains(t, missing.Error, RegistryErrorPrefix, "error is empty for missing module")
+	assert.Equal(t, failure.StageNetwork, missing.ErrorStage)
+	assert.Equal(t, failure.ErrorCodeNotFound, missing.ErrorCode)
+}
+
+func TestResolveRegistryDiscoveryOnce(t *testing.T) {
+	host, client, discoveries := newTestRegistryWithDiscoveries(t, map[string][]string{
+		"example-corp/vpc/aws": {"1.0.0", "1.1.0"},
+		"example-corp/eks/aws": {"1.0.0", "1.1.0"},
+		"example-corp/s3/aws":  {"1.0.0", "1.1.0"},
+	})
+	var modules []scanner.ModuleUsage
+	for _, name := range []string{"vpc", "eks", "s3"} {
+		modules = append(modules, scanner.ModuleUsage{Repo: host + "/example-corp/" + name + "/aws", CurrentVersion: "1.0.0", SourceType: scanner.RegistrySourceType})
+	}
+	results, failureList, err := New(Options{Concurrency: 3, NoCache: true, HTTPClient: client}).Resolve(context.Background(), modules)
+	require.NoError(t, err)
+	assert.Empty(t, failureList)
+	for _, result := range results {
+		assert.Equal(t, []string{"1.1.0"}, result.UpdatesAvailable)
+	}
+	assert.Equal(t, int32(1), discoveries.Load(), "discovery document fetched per module")
+}
+
+func TestResolveModuleRegistryFailure(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/terraform.json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"modules.v1": "/v1/modules/"}`))
+	})
+	mux.HandleFunc("/v1/modules/example-corp/private/aws/versions", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	})
+	mux.HandleFunc("/v1/modules/example-corp/broken/aws/versions", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"modules": [`))
+	})
+	mux.HandleFunc("/v1/modules/example-corp/down/aws/versions", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+	server := httptest.NewTLSServer(mux)
+	host := strings.TrimPrefix(server.URL, "https://")
+	resolver := New(Options{HTTPClient: server.Client()})
+
+	for moduleName, expected := range map[string]struct {
+		stage failure.Stage
+		code  failure.ErrorCode
+	}{
+		"private": {failure.StageAuth, failure.ErrorCodeAuthFailed},
+		"broken":  {failure.StageTagParse, failure.ErrorCodeInvalidResponse},
+		"down":    {failure.StageNetwork, failure.ErrorCodeRegistry},
+	} {
+		result := resolver.ResolveModule(context.Background(), scanner.ModuleUsage{Repo: host + "/example-corp/" + moduleName + "/aws", CurrentVersion: "1.0.0", SourceType: scanner.RegistrySourceType})
+		assert.Contains(t, result.Error, RegistryErrorPrefix)
+		assert.Equal(t, expected.stage, result.ErrorStage, moduleName)
+		assert.Equal(t, expected.code, result.ErrorCode, moduleName)
+	}
+
+	server.Close()
+	unreachable := resolver.ResolveModule(context.Background(), scanner.ModuleUsage{Repo: host + "/example-corp/other/aws", CurrentVersion: "1.0.0", SourceType: scanner.RegistrySourceType})
+	assert.Equal(t, failure.StageNetwork, unreachable.ErrorStage)
+	assert.Equal(t, failure.ErrorCodeUnreachable, unreachable.ErrorCode)
+}
+
+func TestResolveModuleRegistryConstraint(t *testing.T) {
+	host, client := newTestRegistry(t, map[string][]string{
+		"example-corp/vpc/aws": {"4.1.0", "4.2.0", "4.9.1", "5.0.0"},
+		"example-corp/eks/aws": {"4.2.0", "4.9.1"},
+	})
+	resolver := New(Options{HTTPClient: client})
+	module := scanner.ModuleUsage{Repo: host + "/example-corp/vpc/aws", CurrentVersion: "~> 4.2", SourceType: scanner.RegistrySourceType}
+
+	result := resolver.ResolveModule(context.Background(), module)
+	assert.Empty(t, result.Error, "resolver :: ResolveModule :: error is not empty")
+	assert.Equal(t, []string{"4.9.1", "5.0.0"}, result.UpdatesAvailable, "updates are not listed from the lowest version satisfying the constraint")
+	assert.Equal(t, "4.9.1", result.LatestMatchingVersion)
+	assert.Equal(t, "5.0.0", result.LatestVersion)
+	assert.True(t, *result.ConstraintExcludesLatest, "constraint excluding the latest release is not flagged")
+	assert.Equal(t, SeverityMajor, result.UpgradeSeverity)
+
+	module.CurrentVersion = ">= 3.0, < 5.0"
+	result = resolver.ResolveModule(context.Background(), module)
+	assert.Equal(t, []string{"4.2.0", "4.9.1", "5.0.0"}, result.UpdatesAvailable)
+	assert.Equal(t, "4.9.1", result.LatestMatchingVersion)
+
+	module.Repo, module.CurrentVersion = host+"/example-corp/eks/aws", "~> 4.2"
+	allowed := resolver.ResolveModule(context.Background(), module)
+	assert.Equal(t, "4.9.1", allowed.LatestMatchingVersion)
+	assert.Equal(t, "4.9.1", allowed.LatestVersion)
+	assert.False(t, *allowed.ConstraintExcludesLatest, "constraint allowing the latest release is flagged")
+	assert.Empty(t, allowed.UpgradeSeverity, "severity set when the constraint already allows the latest release")
+	assert.False(t, allowed.IsMajorUpgrade)
+}
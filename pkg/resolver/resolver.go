@@ -0,0 +1,311 @@
+// Package resolver looks up the versions available for module sources and works out the updates of module blocks.
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/rs/zerolog/log"
+	"github.com/thundersparkf/samwise/pkg/failure"
+	"github.com/thundersparkf/samwise/pkg/scanner"
+)
+
+var (
+	urlSchemeRegex = regexp.MustCompile(`^[a-z+]+://`)
+	// user@host:path, without a port after the colon
+	scpLikeUrlRegex = regexp.MustCompile(`^([^/:]+):([^0-9/][^/]*/.*|[^0-9/][^/]*)$`)
+)
+
+// Options of a Resolver
+type Options struct {
+	// Number of module sources looked up in parallel, at least one
+	Concurrency int
+	// Directory tag listings are cached in across runs, caching is off when empty or NoCache is set
+	CacheDir string
+	// How long cached tag listings are used before they are fetched again
+	CacheTTL time.Duration
+	NoCache  bool
+	// Cached tag listings are used but fetched ones aren't written to the cache
+	ReadOnlyCache bool
+	// Basic auth for https git sources
+	GitUser string
+	GitKey  string
+	// Private key for ssh git sources
+	GitSSHKeyPath string
+	// Bearer token sent to registries
+	RegistryToken string
+	// How versions are read off the tags of the sources they apply to, the first rule that applies is used
+	TagRules []TagRule
+	// Client for registry requests, one with a 30 second timeout when nil
+	HTTPClient *http.Client
+	// Called after every lookup with the number of lookups done and the total
+	Progress func(done int, total int)
+}
+
+// Resolver looks up every module source at most once for its whole lifetime, keyed by normalized url
+type Resolver struct {
+	options Options
+	mu      sync.Mutex
+	lookups map[string]*tagLookup
+	clones  map[string]*cloneLookup
+	// modules.v1 urls of registry hosts
+	discoveries map[string]*discoveryLookup
+}
+
+type tagLookup struct {
+	once sync.Once
+	tags []string
+	err  error
+}
+
+type cloneLookup struct {
+	once sync.Once
+	repo *git.Repository
+	err  error
+}
+
+type discoveryLookup struct {
+	once       sync.Once
+	modulesUrl string
+	err        error
+}
+
+func New(options Options) *Resolver {
+	if options.HTTPClient == nil {
+		options.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Resolver{options: options, lookups: make(map[string]*tagLookup), clones: make(map[string]*cloneLookup), discoveries: make(map[string]*discoveryLookup)}
+}
+
+// Returns the tags for key, calling fetch only for the first caller. Concurrent callers for the same key wait for it.
+func (r *Resolver) resolve(key string, fetch func() ([]string, error)) ([]string, error) {
+	r.mu.Lock()
+	lookup, ok := r.lookups[key]
+	if !ok {
+		lookup = &tagLookup{}
+		r.lookups[key] = lookup
+	}
+	r.mu.Unlock()
+	lookup.once.Do(func() {
+		log.Debug().Msgf("resolver :: resolve :: fetching :: %s", key)
+		lookup.tags, lookup.err = fetch()
+	})
+	return lookup.tags, lookup.err
+}
+
+// NormalizeRepoUrl turns the different spellings of a git url(git::https://, github.com/..., git@github.com:, ssh://)
+// into <host>/<path>, the key sources are looked up by
+func NormalizeRepoUrl(url string) string {
+	url = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(url), "git::"))
+	if strings.HasPrefix(url, "file://") {
+		return strings.TrimSuffix(url, "/")
+	}
+	url = urlSchemeRegex.ReplaceAllString(url, "")
+	// Drop credentials and ssh users
+	if at := strings.Index(url, "@"); at >= 0 && at < strings.IndexAny(url+"/", "/") {
+		url = url[at+1:]
+	}
+	url = scpLikeUrlRegex.ReplaceAllString(url, "$1/$2")
+	host, path, _ := strings.Cut(url, "/")
+	url = strings.ToLower(host) + "/" + path
+	url = strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+	return strings.TrimSuffix(url, "/")
+}
+
+// Key a module source is resolved and cached by
+func getModuleSourceKey(sourceType string, repo string) string {
+	if sourceType == scanner.RegistrySourceType {
+		host, moduleAddress := scanner.SplitRegistryRepo(repo)
+		return scanner.RegistrySourceType + "::" + strings.ToLower(scanner.RegistryRepoLink(host, moduleAddress))
+	}
+	return NormalizeRepoUrl(repo)
+}
+
+// Tag names of a git repository, listed at most once per resolver and cached across runs
+func (r *Resolver) getGitTags(ctx context.Context, url string) ([]string, error) {
+	key := getModuleSourceKey(scanner.GitSourceType, url)
+	return r.resolve(key, func() ([]string, error) {
+		return r.getTagsWithCache(key, func() ([]string, error) {
+			return r.listRemoteTags(ctx, url)
+		})
+	})
+}
+
+// Versions of a registry module, listed at most once per resolver and cached across runs
+func (r *Resolver) getRegistryModuleVersions(ctx context.Context, repo string) ([]string, error) {
+	key := getModuleSourceKey(scanner.RegistrySourceType, repo)
+	host, moduleAddress := scanner.SplitRegistryRepo(repo)
+	return r.resolve(key, func() ([]string, error) {
+		return r.getTagsWithCache(key, func() ([]string, error) {
+			return r.getRegistryVersions(ctx, host, moduleAddress)
+		})
+	})
+}
+
+// Versions returns the tags of a git source or the versions of a registry module
+func (r *Resolver) Versions(ctx context.Context, sourceType string, repo string) ([]string, error) {
+	if sourceType == scanner.RegistrySourceType {
+		versions, err := r.getRegistryModuleVersions(ctx, repo)
+		if err != nil {
+			return nil, fmt.Errorf(RegistryErrorPrefix+"%w", err)
+		}
+		return versions, nil
+	}
+	return r.getGitTags(ctx, repo)
+}
+
+// Tells the tags of the source apart from branches for refs the scanner couldn't classify
+func getPinning(module scanner.ModuleUsage, versions []string) string {
+	pinning := module.Pinning
+	if pinning == "" {
+		pinning = scanner.GetPinning(module.CurrentVersion)
+	}
+	if pinning != scanner.PinningUnknown {
+		return pinning
+	}
+	if slices.Contains(versions, module.CurrentVersion) {
+		return scanner.PinningTag
+	}
+	return scanner.PinningBranch
+}
+
+// Works out the updates of the module from the versions of its source, failing when the tag in use can't be compared
+// with them. Unpinned modules have no updates, only the latest version they could be pinned to.
+func getUpdateResult(module scanner.ModuleUsage, versions []string) UpdateResult {
+	module.Pinning = getPinning(module, versions)
+	if module.IsUnpinned() {
+		log.Debug().Msgf("resolver :: getUpdateResult :: repo :: %s :: unpinned :: %s", module.Repo, module.Pinning)
+		return UpdateResult{ModuleUsage: module, LatestVersion: GreatestVersion(versions)}
+	}
+	if currentVersion := module.CurrentVersion; !isVersion(currentVersion) && !isVersionConstraint(currentVersion) {
+		err := errors.New(VersionParsingErrorPrefix + currentVersion)
+		return NewFailedResult(module, failure.NewFailure(failure.StageTagParse, failure.ErrorCodeInvalidVersion, err))
+	}
+	updateResult := UpdateResult{ModuleUsage: module}
+	updates, latestMatchingVersion := getUpdatesFromVersions(versions, module.CurrentVersion)
+	if len(updates) > 0 {
+		updateResult.UpdatesAvailable = updates
+		updateResult.LatestVersion = GreatestVersion(updates)
+		currentVersion := module.CurrentVersion
+		if isVersionConstraint(currentVersion) {
+			// Constraints report what the next init resolves to next to the newest release overall
+			constraintExcludesLatest := isExcludedByConstraint(currentVersion, updateResult.LatestVersion)
+			updateResult.LatestMatchingVersion = latestMatchingVersion
+			updateResult.ConstraintExcludesLatest = &constraintExcludesLatest
+			currentVersion = latestMatchingVersion
+		}
+		updateResult.UpgradeSeverity = getUpgradeSeverity(currentVersion, updateResult.LatestVersion)
+		updateResult.IsMajorUpgrade = isMajorReleaseUpgrade(currentVersion, updateResult.LatestVersion)
+	}
+	log.Debug().Msgf("resolver :: getUpdateResult :: repo :: %s :: current :: %s :: updates_available :: %v :: latest_update :: %s", module.Repo, module.CurrentVersion, updateResult.UpdatesAvailable, updateResult.LatestVersion)
+	return updateResult
+}
+
+// ResolveModule returns the updates available for a single module block, with the Error fields set when it failed
+func (r *Resolver) ResolveModule(ctx context.Context, module scanner.ModuleUsage) UpdateResult {
+	updateResult := r.ResolveModuleVersions(ctx, module)
+	if updateResult.SourceType != scanner.RegistrySourceType && slices.Contains([]string{scanner.PinningCommit, scanner.PinningBranch}, updateResult.Pinning) {
+		return r.getRefUpdateResult(ctx, updateResult, r.getTagRule(module))
+	}
+	return updateResult
+}
+
+// ResolveModuleVersions is ResolveModule without cloning git sources to set Ref for commit and branch refs
+func (r *Resolver) ResolveModuleVersions(ctx context.Context, module scanner.ModuleUsage) UpdateResult {
+	versions, err := r.Versions(ctx, module.SourceType, module.Repo)
+	if err != nil {
+		return NewFailedResult(module, err)
+	}
+	return getTagRuleUpdateResult(module, versions, r.getTagRule(module))
+}
+
+// Resolves the modules a source at a time, at most Concurrency in flight, each lookup memoized for the other modules
+func (r *Resolver) lookupSources(ctx context.Context, modules []scanner.ModuleUsage, sourceModules [][]int) []UpdateResult {
+	updateResults := make([]UpdateResult, len(modules))
+	if len(sourceModules) == 0 {
+		return updateResults
+	}
+	concurrency := max(1, min(r.options.Concurrency, len(sourceModules)))
+	log.Debug().Msgf("resolver :: lookupSources :: lookups :: %d :: workers :: %d", len(sourceModules), concurrency)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var progressMu sync.Mutex
+	done := 0
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				// Every worker writes the results of its own modules only
+				for _, m := range sourceModules[i] {
+					updateResults[m] = r.ResolveModule(ctx, modules[m])
+				}
+				log.Debug().Msgf("resolver :: lookupSources :: repo :: %s :: modules :: %d", modules[sourceModules[i][0]].Repo, len(sourceModules[i]))
+				if r.options.Progress != nil {
+					progressMu.Lock()
+					done++
+					r.options.Progress(done, len(sourceModules))
+					progressMu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range sourceModules {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return updateResults
+}
+
+// Resolve looks up every unique module source in parallel and returns the updates of each module and the failures
+func (r *Resolver) Resolve(ctx context.Context, modules []scanner.ModuleUsage) ([]UpdateResult, []UpdateResult, error) {
+	var failureList []UpdateResult
+	var sourceModules [][]int
+	var localModules []int
+	sourceIndex := make(map[string]int)
+	for m, module := range modules {
+		if scanner.IsLocalSource(module.Source) || scanner.IsLocalSource(module.Repo) {
+			log.Debug().Msgf("resolver :: Resolve :: skipping local source :: %s", module.Repo)
+			localModules = append(localModules, m)
+			continue
+		}
+		key := getModuleSourceKey(module.SourceType, module.Repo)
+		i, ok := sourceIndex[key]
+		if !ok {
+			i = len(sourceModules)
+			sourceIndex[key] = i
+			sourceModules = append(sourceModules, nil)
+		}
+		sourceModules[i] = append(sourceModules[i], m)
+	}
+	log.Info().Msgf("Checking %d modules from %d sources ...", len(modules)-len(localModules), len(sourceModules))
+	updateResults := r.lookupSources(ctx, modules, sourceModules)
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	for _, m := range localModules {
+		updateResults[m] = UpdateResult{ModuleUsage: modules[m]}
+	}
+
+	failedLookups := make(map[int]bool)
+	for m, updateResult := range updateResults {
+		if updateResult.Error == "" {
+			continue
+		}
+		// Versions that can't be compared are particular to the module block, lookups are shared by the source
+		if i := sourceIndex[getModuleSourceKey(modules[m].SourceType, modules[m].Repo)]; updateResult.ErrorStage == failure.StageTagParse || !failedLookups[i] {
+			failedLookups[i] = true
+			failureList = append(failureList, updateResult)
+		}
+	}
+	return updateResults, failureList, nil
+}
@@ -0,0 +1,43 @@
+package resolver
+
+import (
+	"github.com/thundersparkf/samwise/pkg/failure"
+	"github.com/thundersparkf/samwise/pkg/scanner"
+)
+
+const SeverityMajor = "major"
+const SeverityMinor = "minor"
+const SeverityPatch = "patch"
+
+// UpdateResult is the outcome of checking a module block for newer versions of its source
+type UpdateResult struct {
+	scanner.ModuleUsage
+	// Versions newer than the current version(or the lowest version satisfying a constraint) in the order they were listed
+	UpdatesAvailable []string `json:"updates_available"`
+	LatestVersion    string   `json:"latest_version,omitempty"`
+	// Only set for version constraints
+	LatestMatchingVersion    string `json:"latest_matching_version,omitempty"`
+	ConstraintExcludesLatest *bool  `json:"constraint_excludes_latest,omitempty"`
+	// Only set for commit and branch refs of git sources
+	Ref *RefInfo `json:"ref,omitempty"`
+	// major, minor or patch, empty when there are no updates or the versions aren't semver
+	UpgradeSeverity string `json:"upgrade_severity,omitempty"`
+	IsMajorUpgrade  bool   `json:"is_major_upgrade"`
+	// Set when the versions of the source could not be looked up or compared
+	Error string `json:"error,omitempty"`
+	// Step the lookup failed at and the kind of failure, see failure.Stage and failure.ErrorCode
+	ErrorStage failure.Stage     `json:"error_stage,omitempty"`
+	ErrorCode  failure.ErrorCode `json:"error_code,omitempty"`
+}
+
+// NewFailedResult is the result of a module block that failed with err, with the stage and code of the
+// failure.Failure in its chain
+func NewFailedResult(module scanner.ModuleUsage, err error) UpdateResult {
+	result := UpdateResult{ModuleUsage: module, Error: err.Error()}
+	result.ErrorStage, result.ErrorCode = failure.FailureOf(err)
+	return result
+}
+
+func (result UpdateResult) HasUpdates() bool {
+	return len(result.UpdatesAvailable) > 0
+}
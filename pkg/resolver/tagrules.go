@@ -0,0 +1,121 @@
+package resolver
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/rs/zerolog/log"
+	"github.com/thundersparkf/samwise/pkg/scanner"
+)
+
+// TagRule is how the versions of the sources it applies to are read off their tag names, for repositories whose tags
+// aren't plain versions(e.g. vpc-v1.2.0 or modules/vpc/1.2.0 in monorepos) or whose pre-releases shouldn't be updates
+type TagRule struct {
+	// Sources the rule applies to, matched against the normalized repo url(github.com/org/modules) or registry address
+	// followed by //<submodule> when the source has one. Every source when nil.
+	Repo *regexp.Regexp
+	// Versions are only read from tags starting with Prefix, with the prefix cut off, e.g. vpc- for vpc-v1.2.0
+	Prefix string
+	// Versions are only read from tags matching Pattern, from its version group or else its first group, e.g.
+	// ^modules/vpc/(?P<version>.+)$
+	Pattern *regexp.Regexp
+	// Leaves out pre-releases like 2.0.0-rc1 from the versions to update to
+	ExcludePrereleases bool
+	// Tags or versions never updated to, e.g. a release that was pulled
+	Ignore []string
+}
+
+// Reads the version off the tag name with the prefix and pattern of the rule, false when the tag doesn't match them
+func (rule *TagRule) parseTag(tag string) (string, bool) {
+	versionString, ok := strings.CutPrefix(tag, rule.Prefix)
+	if !ok {
+		return "", false
+	}
+	if rule.Pattern == nil {
+		return versionString, true
+	}
+	match := rule.Pattern.FindStringSubmatch(versionString)
+	group := rule.Pattern.SubexpIndex("version")
+	if group < 0 {
+		group = 1
+	}
+	if match == nil || len(match) <= group {
+		return "", false
+	}
+	return match[group], true
+}
+
+// Version returns the version read off the tag name, false when the tag isn't a version to update to under the rule.
+// Without a rule every tag that is a version is one.
+func (rule *TagRule) Version(tag string) (string, bool) {
+	if rule == nil {
+		return tag, isVersion(tag)
+	}
+	versionString, ok := rule.parseTag(tag)
+	if !ok {
+		return "", false
+	}
+	parsedVersion, err := version.NewVersion(versionString)
+	if err != nil {
+		return "", false
+	}
+	if rule.ExcludePrereleases && parsedVersion.Prerelease() != "" {
+		return "", false
+	}
+	if slices.Contains(rule.Ignore, tag) || slices.Contains(rule.Ignore, versionString) {
+		return "", false
+	}
+	return versionString, true
+}
+
+// First of the tag rules that applies to the module source, nil when none do
+func (r *Resolver) getTagRule(module scanner.ModuleUsage) *TagRule {
+	key := strings.TrimPrefix(getModuleSourceKey(module.SourceType, module.Repo), scanner.RegistrySourceType+"::")
+	if module.Submodule != "" {
+		key += "//" + module.Submodule
+	}
+	for i, rule := range r.options.TagRules {
+		if rule.Repo == nil || rule.Repo.MatchString(key) {
+			log.Debug().Msgf("tagrules :: getTagRule :: source :: %s :: rule :: %d", key, i)
+			return &r.options.TagRules[i]
+		}
+	}
+	return nil
+}
+
+// Works out the updates of the module from the versions the rule reads off the tags of its source. The tag names are
+// reported rather than the versions read off them so they can be written back as refs.
+func getTagRuleUpdateResult(module scanner.ModuleUsage, tags []string, rule *TagRule) UpdateResult {
+	if rule == nil {
+		return getUpdateResult(module, tags)
+	}
+	tagsByVersion := make(map[string]string)
+	var versions []string
+	for _, tag := range tags {
+		versionString, ok := rule.Version(tag)
+		if _, seen := tagsByVersion[versionString]; ok && !seen {
+			tagsByVersion[versionString] = tag
+			versions = append(versions, versionString)
+		}
+	}
+	// Refs are classified against every tag, the ones the rule leaves out are tags rather than branches all the same
+	module.Pinning = getPinning(module, tags)
+	currentTag := module.CurrentVersion
+	if versionString, ok := rule.parseTag(currentTag); ok && isVersion(versionString) {
+		module.CurrentVersion, module.Pinning = versionString, scanner.PinningVersion
+	}
+	updateResult := getUpdateResult(module, versions)
+	updateResult.CurrentVersion = currentTag
+	for i, update := range updateResult.UpdatesAvailable {
+		updateResult.UpdatesAvailable[i] = tagsByVersion[update]
+	}
+	if tag, ok := tagsByVersion[updateResult.LatestVersion]; ok {
+		updateResult.LatestVersion = tag
+	}
+	if tag, ok := tagsByVersion[updateResult.LatestMatchingVersion]; ok {
+		updateResult.LatestMatchingVersion = tag
+	}
+	return updateResult
+}
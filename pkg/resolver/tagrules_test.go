@@ -0,0 +1,91 @@
+package resolver
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thundersparkf/samwise/pkg/scanner"
+)
+
+func TestTagRuleVersion(t *testing.T) {
+	var noRule *TagRule
+	versionString, ok := noRule.Version("v1.2.0")
+	assert.True(t, ok)
+	assert.Equal(t, "v1.2.0", versionString)
+	_, ok = noRule.Version("vpc-v1.2.0")
+	assert.False(t, ok)
+
+	prefix := &TagRule{Prefix: "vpc-", ExcludePrereleases: true, Ignore: []string{"vpc-v1.3.0", "1.4.0"}}
+	versionString, ok = prefix.Version("vpc-v1.2.0")
+	assert.True(t, ok)
+	assert.Equal(t, "v1.2.0", versionString)
+	for _, tag := range []string{"eks-v1.2.0", "v1.2.0", "vpc-v2.0.0-rc1", "vpc-v1.3.0", "vpc-1.4.0"} {
+		_, ok = prefix.Version(tag)
+		assert.False(t, ok, tag+" taken for a version")
+	}
+
+	pattern := &TagRule{Pattern: regexp.MustCompile(`^modules/vpc/(?P<version>[^/]+)$`)}
+	versionString, ok = pattern.Version("modules/vpc/1.2.0")
+	assert.True(t, ok)
+	assert.Equal(t, "1.2.0", versionString)
+	versionString, ok = pattern.Version("modules/vpc/2.0.0-rc1")
+	assert.True(t, ok, "pre-release left out without ExcludePrereleases")
+	assert.Equal(t, "2.0.0-rc1", versionString)
+	_, ok = pattern.Version("modules/eks/1.2.0")
+	assert.False(t, ok)
+
+	firstGroup := &TagRule{Pattern: regexp.MustCompile(`^release-(.+)$`)}
+	versionString, ok = firstGroup.Version("release-3.1.0")
+	assert.True(t, ok)
+	assert.Equal(t, "3.1.0", versionString)
+}
+
+func TestGetTagRule(t *testing.T) {
+	vpc := TagRule{Repo: regexp.MustCompile(`^github\.com/org/modules//vpc$`), Prefix: "vpc-"}
+	modules := TagRule{Repo: regexp.MustCompile(`github\.com/org/modules`), Prefix: "modules-"}
+	everything := TagRule{ExcludePrereleases: true}
+	resolver := New(Options{TagRules: []TagRule{vpc, modules, everything}})
+	assert.Equal(t, "vpc-", resolver.getTagRule(scanner.ModuleUsage{Repo: "git::https://github.com/org/modules.git", Submodule: "vpc", SourceType: scanner.GitSourceType}).Prefix)
+	assert.Equal(t, "modules-", resolver.getTagRule(scanner.ModuleUsage{Repo: "git@github.com:org/modules.git", Submodule: "eks", SourceType: scanner.GitSourceType}).Prefix)
+	assert.True(t, resolver.getTagRule(scanner.ModuleUsage{Repo: "terraform-aws-modules/vpc/aws", SourceType: scanner.RegistrySourceType}).ExcludePrereleases)
+	assert.Nil(t, New(Options{TagRules: []TagRule{vpc}}).getTagRule(scanner.ModuleUsage{Repo: "github.com/org/other", SourceType: scanner.GitSourceType}))
+}
+
+func TestGetTagRuleUpdateResult(t *testing.T) {
+	tags := []string{"vpc-v1.0.0", "vpc-v1.1.0", "vpc-v2.0.0-rc1", "vpc-v1.2.0", "eks-v3.0.0", "v9.0.0"}
+	rule := &TagRule{Prefix: "vpc-", ExcludePrereleases: true, Ignore: []string{"v1.2.0"}}
+	result := getTagRuleUpdateResult(scanner.ModuleUsage{Repo: "github.com/org/modules", CurrentVersion: "vpc-v1.0.0", Pinning: scanner.PinningUnknown}, tags, rule)
+	assert.Empty(t, result.Error)
+	assert.Equal(t, "vpc-v1.0.0", result.CurrentVersion, "tag in use not reported as is")
+	assert.Equal(t, scanner.PinningVersion, result.Pinning)
+	assert.Equal(t, []string{"vpc-v1.1.0"}, result.UpdatesAvailable)
+	assert.Equal(t, "vpc-v1.1.0", result.LatestVersion)
+	assert.Equal(t, SeverityMinor, result.UpgradeSeverity)
+
+	branch := getTagRuleUpdateResult(scanner.ModuleUsage{Repo: "github.com/org/modules", CurrentVersion: "main", Pinning: scanner.PinningUnknown}, tags, rule)
+	assert.Equal(t, scanner.PinningBranch, branch.Pinning)
+	assert.Equal(t, "vpc-v1.1.0", branch.LatestVersion)
+
+	otherModule := getTagRuleUpdateResult(scanner.ModuleUsage{Repo: "github.com/org/modules", CurrentVersion: "eks-v3.0.0", Pinning: scanner.PinningUnknown}, tags, rule)
+	assert.Equal(t, scanner.PinningTag, otherModule.Pinning, "tag left out by the rule taken for a branch")
+	assert.NotEmpty(t, otherModule.Error)
+
+	noRule := getTagRuleUpdateResult(scanner.ModuleUsage{Repo: "github.com/org/modules", CurrentVersion: "v1.0.0"}, []string{"v1.0.0", "v2.0.0-rc1"}, nil)
+	assert.Equal(t, []string{"v2.0.0-rc1"}, noRule.UpdatesAvailable)
+}
+
+func TestResolveModuleTagRules(t *testing.T) {
+	repoUrl, commits := newTestGitHistory(t, "vpc-v1.0.0", "vpc-v1.1.0", "", "vpc-v2.0.0-beta")
+	resolver := New(Options{NoCache: true, TagRules: []TagRule{{Repo: regexp.MustCompile(regexp.QuoteMeta(NormalizeRepoUrl(repoUrl))), Prefix: "vpc-", ExcludePrereleases: true}}})
+	result := resolver.ResolveModule(context.Background(), scanner.ModuleUsage{Repo: repoUrl, CurrentVersion: "vpc-v1.0.0", Pinning: scanner.GetPinning("vpc-v1.0.0"), SourceType: scanner.GitSourceType})
+	assert.Empty(t, result.Error)
+	assert.Equal(t, []string{"vpc-v1.1.0"}, result.UpdatesAvailable)
+
+	commit := resolver.ResolveModule(context.Background(), scanner.ModuleUsage{Repo: repoUrl, CurrentVersion: commits[2].String(), Pinning: scanner.PinningCommit, SourceType: scanner.GitSourceType})
+	require.NotNil(t, commit.Ref)
+	assert.Equal(t, "vpc-v1.1.0", commit.Ref.NearestTag)
+	assert.Equal(t, "", commit.Ref.ContainingTag, "pre-release left out by the rule taken for a release")
+}
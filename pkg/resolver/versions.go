@@ -0,0 +1,145 @@
+package resolver
+
+import (
+	"github.com/hashicorp/go-version"
+	"github.com/rs/zerolog/log"
+)
+
+// GreatestVersion returns the newest of the versions, empty when none of them are versions
+func GreatestVersion(tags []string) string {
+	highestTag := ""
+	for _, tag := range tags {
+		if (highestTag == "" && isVersion(tag)) || IsNewer(highestTag, tag) {
+			highestTag = tag
+		}
+	}
+
+	return highestTag
+}
+
+// IsNewer reports whether versionToCheck is greater than currentVersion, false when either can't be parsed
+func IsNewer(currentVersion string, versionToCheck string) bool {
+	currentVersionTag, err := version.NewVersion(currentVersion)
+	if err != nil {
+		return false
+	}
+	versionToCheckTag, err := version.NewVersion(versionToCheck)
+	if err != nil {
+		return false
+	}
+	if versionToCheckTag.GreaterThan(currentVersionTag) {
+		return true
+	}
+	return false
+
+}
+
+func isVersion(currentVersion string) bool {
+	_, err := version.NewVersion(currentVersion)
+	return err == nil
+}
+
+// True when the version is a constraint like "~> 4.2" or ">= 3.0, < 5.0" rather than an exact version
+func isVersionConstraint(currentVersion string) bool {
+	if _, err := version.NewVersion(currentVersion); err == nil {
+		return false
+	}
+	_, err := version.NewConstraint(currentVersion)
+	return err == nil
+}
+
+// Returns the lowest and the newest versions satisfying the constraint in that order, empty if none of them do
+func getVersionsForConstraint(constraintString string, versions []string) (string, string) {
+	constraint, err := version.NewConstraint(constraintString)
+	if err != nil {
+		log.Error().Msgf("versions :: getVersionsForConstraint :: unable to parse constraint %s :: %s", constraintString, err.Error())
+		return "", ""
+	}
+	var lowest, newest *version.Version
+	var lowestString, newestString string
+	for _, versionString := range versions {
+		versionToCheck, err := version.NewVersion(versionString)
+		if err != nil || !constraint.Check(versionToCheck) {
+			continue
+		}
+		if lowest == nil || versionToCheck.LessThan(lowest) {
+			lowest, lowestString = versionToCheck, versionString
+		}
+		if newest == nil || versionToCheck.GreaterThan(newest) {
+			newest, newestString = versionToCheck, versionString
+		}
+	}
+	log.Debug().Msgf("versions :: getVersionsForConstraint :: constraint :: %s :: lowest :: %s :: newest :: %s", constraintString, lowestString, newestString)
+	return lowestString, newestString
+}
+
+// Returns the versions greater than currentVersion and the newest version satisfying currentVersion when it is a
+// constraint. Updates for constraints are listed from the lowest version satisfying them.
+func getUpdatesFromVersions(versions []string, currentVersion string) ([]string, string) {
+	baseline := currentVersion
+	var latestMatchingVersion string
+	if isVersionConstraint(currentVersion) {
+		baseline, latestMatchingVersion = getVersionsForConstraint(currentVersion, versions)
+		if baseline == "" {
+			baseline = "v0.0.0"
+		}
+	}
+	var tagsList []string
+	for _, versionToCheck := range versions {
+		if IsNewer(baseline, versionToCheck) {
+			tagsList = append(tagsList, versionToCheck)
+		}
+	}
+	return tagsList, latestMatchingVersion
+}
+
+func isExcludedByConstraint(constraintString string, versionToCheck string) bool {
+	constraint, err := version.NewConstraint(constraintString)
+	if err != nil {
+		return false
+	}
+	versionToCheckTag, err := version.NewVersion(versionToCheck)
+	if err != nil {
+		return false
+	}
+	return !constraint.Check(versionToCheckTag)
+}
+
+// Returns major, minor or patch for the first version segment that differs, empty when either version can't be parsed
+// or versionToCheck is not greater than currentVersion
+func getUpgradeSeverity(currentVersion string, versionToCheck string) string {
+	currentVersionTag, err := version.NewVersion(currentVersion)
+	if err != nil {
+		return ""
+	}
+	versionToCheckTag, err := version.NewVersion(versionToCheck)
+	if err != nil || !versionToCheckTag.GreaterThan(currentVersionTag) {
+		return ""
+	}
+	currentSegments, segmentsToCheck := currentVersionTag.Segments(), versionToCheckTag.Segments()
+	if segmentsToCheck[0] != currentSegments[0] {
+		return SeverityMajor
+	}
+	if segmentsToCheck[1] != currentSegments[1] {
+		return SeverityMinor
+	}
+	return SeverityPatch
+}
+
+func isMajorReleaseUpgrade(currentVersion string, versionToCheck string) bool {
+	currentVersionTag, err := version.NewVersion(currentVersion)
+	if err != nil {
+		return false
+	}
+	versionToCheckTag, err := version.NewVersion(versionToCheck)
+	if err != nil {
+		return false
+	}
+	versionToCheckTagMajorVersion := versionToCheckTag.Segments()[0]
+	currentVersionTagMajorVersion := currentVersionTag.Segments()[0]
+	if versionToCheckTagMajorVersion == currentVersionTagMajorVersion {
+		return false
+	}
+	return true
+
+}
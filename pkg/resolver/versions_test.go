@@ -0,0 +1,106 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGreatestVersion(t *testing.T) {
+	list1 := []string{"1.0.0", "1.0.1", "1.0.5", "1.0.3-beta", "1.0.3-alpha"}
+	list2 := []string{"1.0.0", "v1.0.1", "v1.0.3-beta", "v1.0.5-alpha"}
+	list3 := []string{"1.0.0", "1.0.1", "1.0.3-beta", "1.0.5-alpha", "v1.0.5-beta"}
+	list4 := []string{"1.0.dwd0", "1.0wvwv.1", "1.0.3-beta", "1.0.5-alpha", "v1.0.5-beta"}
+
+	assert.Equal(t, "1.0.5", GreatestVersion(list1))
+	assert.Equal(t, "v1.0.5-alpha", GreatestVersion(list2))
+	assert.Equal(t, "v1.0.5-beta", GreatestVersion(list3))
+	assert.Equal(t, "v1.0.5-beta", GreatestVersion(list4))
+	assert.Empty(t, GreatestVersion(nil))
+	assert.Empty(t, GreatestVersion([]string{"release", "main"}), "tags that aren't versions returned as the newest version")
+	assert.Equal(t, "v0.0.0", GreatestVersion([]string{"release", "v0.0.0"}))
+
+}
+
+func TestIsMajorReleaseUpgrade(t *testing.T) {
+	minorUpgrade := isMajorReleaseUpgrade("1.2.1", "1.3.2-beta")
+	assert.Equal(t, false, minorUpgrade)
+	majorUpgrade := isMajorReleaseUpgrade("2.2.1", "1.3.2-beta")
+	assert.Equal(t, true, majorUpgrade)
+	failureCurrentVersion := isMajorReleaseUpgrade("random", "1.3.2-beta")
+	assert.Equal(t, false, failureCurrentVersion)
+	failureVersionToCheck := isMajorReleaseUpgrade("1.1.1", "1.3..2-beta")
+	assert.Equal(t, false, failureVersionToCheck)
+}
+
+func TestIsVersionConstraint(t *testing.T) {
+	assert.Equal(t, false, isVersionConstraint("1.2.0"))
+	assert.Equal(t, false, isVersionConstraint("v1.2.0"))
+	assert.Equal(t, false, isVersionConstraint(""))
+	assert.Equal(t, false, isVersionConstraint("main"))
+	assert.Equal(t, true, isVersionConstraint("~> 4.2"))
+	assert.Equal(t, true, isVersionConstraint(">= 3.0, < 5.0"))
+	assert.Equal(t, true, isVersionConstraint("~>4.2"))
+}
+
+func TestGetVersionsForConstraint(t *testing.T) {
+	versions := []string{"3.9.0", "4.2.0", "4.2.7", "4.10.0", "5.0.0", "random"}
+	lowest, newest := getVersionsForConstraint("~> 4.2", versions)
+	assert.Equal(t, "4.2.0", lowest)
+	assert.Equal(t, "4.10.0", newest)
+	lowest, newest = getVersionsForConstraint("~> 4.2.0", versions)
+	assert.Equal(t, "4.2.0", lowest)
+	assert.Equal(t, "4.2.7", newest)
+	lowest, newest = getVersionsForConstraint(">= 6.0", versions)
+	assert.Empty(t, lowest)
+	assert.Empty(t, newest)
+	lowest, newest = getVersionsForConstraint("random", versions)
+	assert.Empty(t, lowest)
+	assert.Empty(t, newest)
+}
+
+func TestIsExcludedByConstraint(t *testing.T) {
+	assert.Equal(t, true, isExcludedByConstraint("~> 4.2", "5.0.0"))
+	assert.Equal(t, false, isExcludedByConstraint("~> 4.2", "4.10.0"))
+	assert.Equal(t, false, isExcludedByConstraint(">= 3.0, < 5.0", "4.9.9"))
+	assert.Equal(t, true, isExcludedByConstraint(">= 3.0, < 5.0", "5.0.0"))
+	assert.Equal(t, false, isExcludedByConstraint("random", "5.0.0"))
+}
+
+func TestGetUpgradeSeverity(t *testing.T) {
+	assert.Equal(t, SeverityMajor, getUpgradeSeverity("1.2.1", "2.0.0"))
+	assert.Equal(t, SeverityMinor, getUpgradeSeverity("v1.2.1", "v1.3.0"))
+	assert.Equal(t, SeverityPatch, getUpgradeSeverity("1.2.1", "1.2.2-beta"))
+	assert.Equal(t, SeverityMinor, getUpgradeSeverity("1", "1.1"))
+	assert.Equal(t, "", getUpgradeSeverity("1.2.1", "1.2.1"))
+	assert.Equal(t, "", getUpgradeSeverity("2.0.0", "1.3.0"))
+	assert.Equal(t, "", getUpgradeSeverity("random", "1.3.0"))
+	assert.Equal(t, "", getUpgradeSeverity("1.2.1", "random"))
+}
+
+func TestIsNewer(t *testing.T) {
+	assert.Equal(t, true, IsNewer("1.0.0", "1.0.1"))
+	assert.Equal(t, false, IsNewer("1.0.0", "0.0.1"))
+	assert.Equal(t, true, IsNewer("1.0.0-alpha", "1.0.0"))
+	assert.Equal(t, true, IsNewer("1.0.0-alpha", "1.0.0-beta"))
+	assert.Equal(t, false, IsNewer("chaos", "1.0.0-beta"))
+	assert.Equal(t, false, IsNewer("1.0.0-beta", "chaos"))
+
+}
+
+func TestGetUpdatesFromVersions(t *testing.T) {
+	updates, latestMatching := getUpdatesFromVersions([]string{"1.0.0", "1.0.1", "random", "v2.0.0"}, "1.0.0")
+	assert.Equal(t, []string{"1.0.1", "v2.0.0"}, updates)
+	assert.Empty(t, latestMatching, "latest matching version set for an exact version")
+
+	updates, latestMatching = getUpdatesFromVersions([]string{"4.1.0", "4.2.0", "4.9.1", "5.0.0"}, "~> 4.2")
+	assert.Equal(t, []string{"4.9.1", "5.0.0"}, updates, "updates are not listed from the lowest version satisfying the constraint")
+	assert.Equal(t, "4.9.1", latestMatching)
+
+	updates, latestMatching = getUpdatesFromVersions([]string{"4.1.0", "4.2.0", "4.9.1", "5.0.0"}, "> 6.0")
+	assert.Equal(t, []string{"4.1.0", "4.2.0", "4.9.1", "5.0.0"}, updates, "every version is an update when none satisfy the constraint")
+	assert.Empty(t, latestMatching)
+
+	updates, _ = getUpdatesFromVersions([]string{"1.0.0"}, "1.0.0")
+	assert.Empty(t, updates)
+}
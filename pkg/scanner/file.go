@@ -0,0 +1,108 @@
+package scanner
+
+import (
+	"os"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/rs/zerolog/log"
+	"github.com/thundersparkf/samwise/pkg/failure"
+)
+
+// A module block as read from a terraform file, before its source is parsed
+type moduleBlock struct {
+	Name      string
+	Source    string
+	Version   string
+	Line      int
+	Column    int
+	EndLine   int
+	EndColumn int
+}
+
+// Returns the range of the source attribute of every module block in the file by module name
+func getModuleSourcePositions(content []byte, path string) map[string]hcl.Range {
+	positions := make(map[string]hcl.Range)
+	file, _ := hclsyntax.ParseConfig(content, path, hcl.Pos{Line: 1, Column: 1})
+	if file == nil {
+		return positions
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return positions
+	}
+	for _, block := range body.Blocks {
+		if block.Type == "module" && len(block.Labels) > 0 {
+			if source, ok := block.Body.Attributes["source"]; ok {
+				positions[block.Labels[0]] = source.SrcRange
+			}
+		}
+	}
+	return positions
+}
+
+// Returns the name, source and version(registry modules only) attributes of every module block in the file, along with
+// the line and column range of the source attribute. Files that can't be read or aren't valid HCL fail at the parse stage.
+func readTfFiles(path string) ([]moduleBlock, error) {
+	var sources = make([]moduleBlock, 0)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, failure.NewFailure(failure.StageParse, failure.ErrorCodeUnreadable, err)
+	}
+	file, diags := hclwrite.ParseConfig(content, path, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, failure.NewFailure(failure.StageParse, failure.ErrorCodeInvalidHCL, diags)
+	}
+	if file == nil {
+		return sources, nil
+	}
+	positions := getModuleSourcePositions(content, path)
+	for _, block := range file.Body().Blocks() {
+		labels := block.Labels()
+		if block.Type() == "module" && len(labels) > 0 {
+			if block.Body().GetAttribute("source") != nil {
+				sourceString := block.Body().GetAttribute("source").Expr().BuildTokens(nil).Bytes()
+				moduleSource := string(sourceString)
+				moduleSource = strings.ReplaceAll(moduleSource, "\"", "")
+				moduleSource = strings.ReplaceAll(moduleSource, " ", "")
+				log.Debug().Msgf("file :: readTfFiles :: sourceString :: %s", moduleSource)
+				var moduleVersion string
+				if block.Body().GetAttribute("version") != nil {
+					versionString := block.Body().GetAttribute("version").Expr().BuildTokens(nil).Bytes()
+					moduleVersion = strings.TrimSpace(strings.ReplaceAll(string(versionString), "\"", ""))
+					log.Debug().Msgf("file :: readTfFiles :: versionString :: %s", moduleVersion)
+				}
+				moduleSourceBlock := moduleBlock{Name: labels[0], Source: moduleSource, Version: moduleVersion}
+				if position, ok := positions[labels[0]]; ok {
+					moduleSourceBlock.Line, moduleSourceBlock.Column = position.Start.Line, position.Start.Column
+					moduleSourceBlock.EndLine, moduleSourceBlock.EndColumn = position.End.Line, position.End.Column
+				}
+				sources = append(sources, moduleSourceBlock)
+			}
+		}
+	}
+	return sources, nil
+}
+
+// ParseFile returns the module blocks of a terraform file with their parsed sources, see ParseSource
+func ParseFile(path string) ([]ModuleUsage, error) {
+	blocks, err := readTfFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	var modules []ModuleUsage
+	for _, block := range blocks {
+		module, ok := ParseSource(block.Source, block.Version)
+		if !ok {
+			continue
+		}
+		module.Name = block.Name
+		module.FileName = path
+		module.Line, module.Column = block.Line, block.Column
+		module.EndLine, module.EndColumn = block.EndLine, block.EndColumn
+		modules = append(modules, module)
+	}
+	return modules, nil
+}
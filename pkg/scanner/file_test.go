@@ -0,0 +1,72 @@
+package scanner
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thundersparkf/samwise/pkg/failure"
+)
+
+func TestReadTfFiles(t *testing.T) {
+	fileContent := `module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.1.2"
+}
+
+module "eks" {
+    source = "git::https://github.com/org/eks.git?ref=v1.0.0"
+}
+`
+	path := t.TempDir() + "/main.tf"
+	err := os.WriteFile(path, []byte(fileContent), os.ModePerm)
+	require.NoError(t, err)
+	sources, err := readTfFiles(path)
+	assert.Empty(t, err)
+	assert.Equal(t, []moduleBlock{
+		{Name: "vpc", Source: "terraform-aws-modules/vpc/aws", Version: "5.1.2", Line: 2, Column: 3, EndLine: 2, EndColumn: 44},
+		{Name: "eks", Source: "git::https://github.com/org/eks.git?ref=v1.0.0", Line: 7, Column: 5, EndLine: 7, EndColumn: 62},
+	}, sources)
+	_, err = readTfFiles(t.TempDir() + "/missing.tf")
+	assert.NotEmpty(t, err, "missing file read without an error")
+}
+
+func TestParseFile(t *testing.T) {
+	fileContent := `
+	module "vpc" {
+	  source  = "terraform-aws-modules/vpc/aws"
+	  version = "5.1.2"
+	}
+	module "eks" {
+	  source = "git::https://github.com/org/eks.git?ref=v1.0.0"
+	}
+	`
+	path := t.TempDir() + "/main.tf"
+	err := os.WriteFile(path, []byte(fileContent), os.ModePerm)
+	require.NoError(t, err)
+	modules, err := ParseFile(path)
+	assert.Empty(t, err)
+	assert.Equal(t, []ModuleUsage{{
+		Name: "vpc", Source: "terraform-aws-modules/vpc/aws", Repo: "terraform-aws-modules/vpc/aws", SourceType: RegistrySourceType, CurrentVersion: "5.1.2", Pinning: PinningVersion,
+		FileName: path, Line: 3, Column: 4, EndLine: 3, EndColumn: 45,
+	}, {
+		Name: "eks", Source: "git::https://github.com/org/eks.git?ref=v1.0.0", Repo: "https://github.com/org/eks.git", SourceType: GitSourceType, CurrentVersion: "v1.0.0", Pinning: PinningVersion,
+		FileName: path, Line: 7, Column: 4, EndLine: 7, EndColumn: 61,
+	}}, modules)
+}
+
+func TestParseFileFailure(t *testing.T) {
+	path := t.TempDir() + "/main.tf"
+	require.NoError(t, os.WriteFile(path, []byte("module \"vpc\" {\n  source = \n"), os.ModePerm))
+	_, err := ParseFile(path)
+	stage, code := failure.FailureOf(err)
+	assert.Equal(t, failure.StageParse, stage)
+	assert.Equal(t, failure.ErrorCodeInvalidHCL, code)
+
+	_, err = ParseFile(t.TempDir() + "/missing.tf")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	stage, code = failure.FailureOf(err)
+	assert.Equal(t, failure.StageParse, stage)
+	assert.Equal(t, failure.ErrorCodeUnreadable, code)
+}
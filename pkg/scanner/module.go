@@ -0,0 +1,69 @@
+package scanner
+
+import (
+	"slices"
+	"strconv"
+)
+
+const RegistrySourceType = "registry"
+const GitSourceType = "git"
+
+// How a module block pins the version of its source. Commits, branches, blocks without a ref or version and refs that
+// couldn't be told apart are unpinned, their updates can't be worked out.
+const PinningVersion = "version"
+const PinningTag = "tag"
+const PinningCommit = "commit"
+const PinningBranch = "branch"
+const PinningNone = "none"
+const PinningUnknown = "unknown"
+
+// ModuleUsage is a module block found in a terraform file
+type ModuleUsage struct {
+	// Label of the module block, e.g. vpc for module "vpc" {}
+	Name string `json:"name"`
+	// Source attribute as written in the module block, empty for files that could not be scanned
+	Source string `json:"source,omitempty"`
+	// Repository url of git sources, [<host>/]<namespace>/<name>/<provider> of registry sources
+	Repo       string `json:"repo"`
+	SourceType string `json:"source_type"`
+	// Ref of git sources, version(or version constraint) of registry sources
+	CurrentVersion string `json:"current_version"`
+	// One of the Pinning constants, empty for files that could not be scanned
+	Pinning   string `json:"pinning,omitempty"`
+	Submodule string `json:"submodule,omitempty"`
+	FileName  string `json:"file_name"`
+	// Range of the source attribute, zero when unknown. The end column is exclusive.
+	Line      int `json:"line,omitempty"`
+	Column    int `json:"column,omitempty"`
+	EndLine   int `json:"end_line,omitempty"`
+	EndColumn int `json:"end_column,omitempty"`
+}
+
+// IsUnpinned reports whether the block is pinned to a commit, a branch, nothing or a ref that couldn't be told apart
+func (usage ModuleUsage) IsUnpinned() bool {
+	return slices.Contains([]string{PinningCommit, PinningBranch, PinningNone, PinningUnknown}, usage.Pinning)
+}
+
+// SourceWithSubmodule is the source as written in the module block, minus the ref
+func (usage ModuleUsage) SourceWithSubmodule() string {
+	if usage.Submodule == "" {
+		return usage.Repo
+	}
+	return usage.Repo + "//" + usage.Submodule
+}
+
+// Location is <file_name>:<line> of the module block, just the file name when the line is unknown
+func (usage ModuleUsage) Location() string {
+	if usage.Line == 0 {
+		return usage.FileName
+	}
+	return usage.FileName + ":" + strconv.Itoa(usage.Line)
+}
+
+// Address is module.<name> as terraform addresses the block, the source when the name is unknown
+func (usage ModuleUsage) Address() string {
+	if usage.Name == "" {
+		return usage.SourceWithSubmodule()
+	}
+	return "module." + usage.Name
+}
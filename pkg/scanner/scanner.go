@@ -0,0 +1,161 @@
+// Package scanner finds the module blocks of terraform code and parses their sources.
+package scanner
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/thundersparkf/samwise/pkg/failure"
+)
+
+// Extension of the terraform files that are scanned
+const tfExtension = ".tf"
+
+// Options of a Scanner
+type Options struct {
+	// Directory containing the terraform code
+	Path string
+	// Levels of subdirectories scanned below Path, -1 for all of them and 0 for Path alone
+	Depth int
+	// Names of directories that are skipped along with everything below them
+	Ignore []string
+	// Called with every directory or file below Path that can't be read or parsed, the error is an failure.Failure
+	// of the parse stage. Returning nil skips it and the scan goes on, any other error stops the scan. The scan stops at
+	// the first of them when nil.
+	OnError func(path string, err error) error
+}
+
+// Scanner reads the module blocks of every terraform file in a directory tree
+type Scanner struct {
+	options Options
+}
+
+func New(options Options) *Scanner {
+	options.Path = fixTrailingSlashForPath(options.Path)
+	return &Scanner{options: options}
+}
+
+// Returns whether the entry is a directory and fs.SkipDir for directories that are ignored or deeper than the depth
+func (s *Scanner) directorySearch(rootDir string, path string, d fs.DirEntry) (bool, error) {
+	depthCountInCurrentPath := strings.Count(rootDir, string(os.PathSeparator))
+	if d.IsDir() {
+		if !slices.Contains(s.options.Ignore, d.Name()) {
+			log.Info().Msg("scanner :: directorySearch :: in directory " + path)
+			if s.options.Depth != -1 {
+				if strings.Count(path, string(os.PathSeparator)) > depthCountInCurrentPath+s.options.Depth {
+					log.Info().Msg("...which is skipped")
+					return true, fs.SkipDir
+				}
+			}
+			return true, nil
+		}
+		return true, fs.SkipDir
+	}
+	return false, nil
+}
+
+// Passes the error of path to OnError, as a parse stage failure.Failure when it isn't one already
+func (s *Scanner) handleError(path string, err error) error {
+	if stage, _ := failure.FailureOf(err); stage == "" {
+		err = failure.NewFailure(failure.StageParse, failure.ErrorCodeUnreadable, err)
+	}
+	log.Debug().Msgf("scanner :: handleError :: path :: %s :: %s", path, err.Error())
+	if s.options.OnError == nil {
+		return err
+	}
+	return s.options.OnError(path, err)
+}
+
+// Directories returns every directory that is scanned, Path first. Path itself has to be readable.
+func (s *Scanner) Directories() ([]string, error) {
+	var directories []string
+	rootDir := s.options.Path
+	err := filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == rootDir {
+				return err
+			}
+			if handlerErr := s.handleError(path, err); handlerErr != nil {
+				return handlerErr
+			}
+			// Directories that can't be listed were already added before their entries were read
+			if len(directories) > 0 && directories[len(directories)-1] == path {
+				directories = directories[:len(directories)-1]
+			}
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		isAllowedDir, dirError := s.directorySearch(rootDir, path, d)
+		if errors.Is(dirError, fs.SkipDir) {
+			return dirError
+		}
+		if isAllowedDir {
+			directories = append(directories, path)
+		}
+		return nil
+	})
+	return directories, err
+}
+
+// ScanDirectory returns the module blocks of the terraform(.tf) files directly in path, see Options.OnError for the files
+// that can't be read
+func (s *Scanner) ScanDirectory(path string) ([]ModuleUsage, error) {
+	path = fixTrailingSlashForPath(path)
+	files, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	return s.scanFiles(path, files)
+}
+
+func (s *Scanner) scanFiles(path string, files []fs.DirEntry) ([]ModuleUsage, error) {
+	var moduleRepoList []ModuleUsage
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != tfExtension {
+			continue
+		}
+		modules, err := ParseFile(path + "/" + file.Name())
+		if err != nil {
+			if handlerErr := s.handleError(path+"/"+file.Name(), err); handlerErr != nil {
+				return moduleRepoList, handlerErr
+			}
+			continue
+		}
+		moduleRepoList = append(moduleRepoList, modules...)
+	}
+	return moduleRepoList, nil
+}
+
+// Scan returns the module blocks of every scanned directory, see Options.OnError for the directories and files that
+// can't be read
+func (s *Scanner) Scan() ([]ModuleUsage, error) {
+	directories, err := s.Directories()
+	if err != nil {
+		return nil, err
+	}
+	var modules []ModuleUsage
+	for _, directory := range directories {
+		log.Info().Msg("Scanning directory " + directory + " ...")
+		files, err := os.ReadDir(directory)
+		if err != nil {
+			if handlerErr := s.handleError(directory, err); handlerErr != nil {
+				return modules, handlerErr
+			}
+			continue
+		}
+		directoryModules, err := s.scanFiles(directory, files)
+		if err != nil {
+			return modules, err
+		}
+		log.Debug().Msgf("scanner :: Scan :: modules :: %v", directoryModules)
+		modules = append(modules, directoryModules...)
+	}
+	return modules, nil
+}
@@ -0,0 +1,193 @@
+package scanner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thundersparkf/samwise/pkg/failure"
+)
+
+// Functions to help testing
+
+// Writes the files(paths relative to the returned directory) of a terraform tree
+func newTestTree(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), os.ModePerm))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), os.ModePerm))
+	}
+	return dir
+}
+
+func TestDirectories(t *testing.T) {
+	dir := newTestTree(t, map[string]string{
+		"main.tf":                  "",
+		"modules/vpc/main.tf":      "",
+		".terraform/cache/main.tf": "",
+	})
+	directories, err := New(Options{Path: dir + "/", Depth: 0}).Directories()
+	assert.Empty(t, err)
+	assert.Equal(t, []string{dir}, directories)
+
+	directories, err = New(Options{Path: dir, Depth: 1, Ignore: []string{".terraform"}}).Directories()
+	assert.Empty(t, err)
+	assert.Equal(t, []string{dir, dir + "/modules"}, directories)
+
+	directories, err = New(Options{Path: dir, Depth: -1, Ignore: []string{".terraform"}}).Directories()
+	assert.Empty(t, err)
+	assert.Equal(t, []string{dir, dir + "/modules", dir + "/modules/vpc"}, directories)
+
+	_, err = New(Options{Path: dir + "/missing"}).Directories()
+	assert.NotEmpty(t, err, "missing path walked without an error")
+}
+
+func TestScanDirectory(t *testing.T) {
+	dir := newTestTree(t, map[string]string{"main.tf": `
+	module "test"{
+	  source         = "git::https://github.com/Darth-Tech/terraform-modules//modules/fargate-cluster?ref=v1.0.2"
+  	  cluster_name   = "my-ecs"
+	}
+	`})
+	data, err := New(Options{}).ScanDirectory(dir + "/")
+	assert.Empty(t, err)
+	assert.Equal(t, 1, len(data))
+	assert.Equal(t, "https://github.com/Darth-Tech/terraform-modules", data[0].Repo)
+	assert.Equal(t, "v1.0.2", data[0].CurrentVersion)
+	assert.Equal(t, "modules/fargate-cluster", data[0].Submodule)
+	assert.Equal(t, dir+"/main.tf", data[0].FileName)
+}
+
+func TestScanDirectoryLocalModule(t *testing.T) {
+	dir := newTestTree(t, map[string]string{"main.tf": `
+module "local" {
+  source = "./modules/vpc"
+}
+
+module "shared" {
+  source = "../shared"
+}
+
+module "vpc" {
+  source = "git::https://github.com/org/vpc.git?ref=v1.0.0"
+}
+`})
+	data, err := New(Options{}).ScanDirectory(dir)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(data), "local module sources not skipped")
+	assert.Equal(t, "vpc", data[0].Name)
+}
+
+func TestScanDirectoryRegistry(t *testing.T) {
+	dir := newTestTree(t, map[string]string{"main.tf": `
+	module "vpc" {
+	  source  = "terraform-aws-modules/vpc/aws"
+	  version = "5.1.2"
+	}
+	module "node_pool" {
+	  source  = "app.terraform.io/example-corp/k8s-cluster/azurerm//modules/node-pool"
+	  version = "~> 1.2"
+	}
+	`})
+	data, err := New(Options{}).ScanDirectory(dir)
+	assert.Empty(t, err)
+	assert.Equal(t, 2, len(data))
+	assert.Equal(t, "terraform-aws-modules/vpc/aws", data[0].Repo)
+	assert.Equal(t, "5.1.2", data[0].CurrentVersion)
+	assert.Equal(t, RegistrySourceType, data[0].SourceType)
+	assert.Equal(t, "app.terraform.io/example-corp/k8s-cluster/azurerm", data[1].Repo)
+	assert.Equal(t, "~> 1.2", data[1].CurrentVersion)
+	assert.Equal(t, "modules/node-pool", data[1].Submodule)
+	assert.Equal(t, "node_pool", data[1].Name)
+	assert.Equal(t, PinningVersion, data[1].Pinning)
+	assert.Equal(t, 7, data[1].Line)
+	assert.Equal(t, 4, data[1].Column)
+}
+
+func TestScan(t *testing.T) {
+	dir := newTestTree(t, map[string]string{
+		"main.tf":              "module \"vpc\" {\n  source  = \"terraform-aws-modules/vpc/aws\"\n  version = \"5.1.2\"\n}\n",
+		"modules/eks/main.tf":  "module \"eks\" {\n  source = \"git::https://github.com/org/eks.git?ref=v1.0.0\"\n}\n",
+		"modules/eks/notes.md": "module \"ignored\" {\n  source = \"terraform-aws-modules/ignored/aws\"\n}\n",
+		"README.md":            "# Not HCL {",
+	})
+	modules, err := New(Options{Path: dir, Depth: -1}).Scan()
+	assert.Empty(t, err)
+	assert.Equal(t, 2, len(modules))
+	assert.Equal(t, "vpc", modules[0].Name)
+	assert.Equal(t, "eks", modules[1].Name)
+	assert.Equal(t, dir+"/modules/eks/main.tf", modules[1].FileName)
+
+	_, err = New(Options{Path: dir + "/missing"}).Scan()
+	assert.NotEmpty(t, err)
+}
+
+func TestScanOnError(t *testing.T) {
+	dir := newTestTree(t, map[string]string{
+		"main.tf":             "module \"vpc\" {\n  source  = \"terraform-aws-modules/vpc/aws\"\n  version = \"5.1.2\"\n}\n",
+		"modules/eks/main.tf": "module \"eks\" {\n  source = \"git::https://github.com/org/eks.git?ref=v1.0.0\"\n}\n",
+	})
+	// A file that can't be read
+	require.NoError(t, os.Symlink(dir+"/missing.tf", dir+"/broken.tf"))
+
+	_, err := New(Options{Path: dir, Depth: -1}).Scan()
+	assert.ErrorIs(t, err, os.ErrNotExist, "scan not stopped without OnError")
+
+	var failedPaths []string
+	var failedStages []failure.Stage
+	modules, err := New(Options{Path: dir, Depth: -1, OnError: func(path string, err error) error {
+		failedPaths = append(failedPaths, path)
+		stage, _ := failure.FailureOf(err)
+		failedStages = append(failedStages, stage)
+		return nil
+	}}).Scan()
+	require.NoError(t, err)
+	assert.Equal(t, []string{dir + "/broken.tf"}, failedPaths)
+	assert.Equal(t, []failure.Stage{failure.StageParse}, failedStages)
+	assert.Equal(t, 2, len(modules), "modules of the readable files not scanned")
+
+	stop := errors.New("stop")
+	_, err = New(Options{Path: dir, Depth: -1, OnError: func(path string, err error) error {
+		return stop
+	}}).Scan()
+	assert.ErrorIs(t, err, stop)
+}
+
+func TestScanOnErrorDirectory(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("directories are always readable by root")
+	}
+	dir := newTestTree(t, map[string]string{
+		"main.tf":             "module \"vpc\" {\n  source  = \"terraform-aws-modules/vpc/aws\"\n  version = \"5.1.2\"\n}\n",
+		"modules/eks/main.tf": "module \"eks\" {\n  source = \"git::https://github.com/org/eks.git?ref=v1.0.0\"\n}\n",
+	})
+	require.NoError(t, os.Chmod(dir+"/modules/eks", 0o000))
+	t.Cleanup(func() { _ = os.Chmod(dir+"/modules/eks", 0o755) })
+
+	var failedPaths []string
+	modules, err := New(Options{Path: dir, Depth: -1, OnError: func(path string, err error) error {
+		failedPaths = append(failedPaths, path)
+		_, code := failure.FailureOf(err)
+		assert.Equal(t, failure.ErrorCodeUnreadable, code)
+		return nil
+	}}).Scan()
+	require.NoError(t, err)
+	assert.Equal(t, []string{dir + "/modules/eks"}, failedPaths, "unreadable directory not reported once")
+	assert.Equal(t, 1, len(modules))
+}
+
+func TestModuleUsage(t *testing.T) {
+	module := ModuleUsage{Name: "vpc", Repo: "github.com/org/vpc", Submodule: "modules/subnets", FileName: "main.tf", Line: 3}
+	assert.Equal(t, "github.com/org/vpc//modules/subnets", module.SourceWithSubmodule())
+	assert.Equal(t, "main.tf:3", module.Location())
+	assert.Equal(t, "module.vpc", module.Address())
+	assert.False(t, module.IsUnpinned(), "block without a pinning unpinned")
+	module = ModuleUsage{Repo: "github.com/org/vpc", FileName: "main.tf", Pinning: PinningBranch}
+	assert.True(t, module.IsUnpinned())
+	assert.Equal(t, "github.com/org/vpc", module.SourceWithSubmodule())
+	assert.Equal(t, "main.tf", module.Location())
+	assert.Equal(t, "github.com/org/vpc", module.Address())
+}
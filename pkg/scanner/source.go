@@ -0,0 +1,239 @@
+package scanner
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/exp/maps"
+)
+
+const PublicRegistryHost = "registry.terraform.io"
+
+var (
+	// Regex to check if the line has "source=" in it
+	// sourceLineRegex      = regexp.MustCompile(`(?mU).*module\".+\".*{.*source="(.+\..+)".*}.*`)
+	submoduleRegex       = regexp.MustCompile(`(?P<base_url>.*/.*)//(?P<submodule>.*)`)
+	moduleSourceRegexMap = map[string]*regexp.Regexp{
+		"generic_git": regexp.MustCompile(`\"git::(.+)\"`),
+		"github":      regexp.MustCompile(`\"(.*github.com.+)\"`),
+		"https":       regexp.MustCompile(`\"(https://.+)\"`),
+		"bitbucket":   regexp.MustCompile(`\".*(bitbucket.org.+)\"`),
+	}
+	removeUrlParams = regexp.MustCompile(`(\?.*)`)
+	refRegex        = regexp.MustCompile(".*?ref=(.*)&.*|.*?ref=(.*)")
+	// Registry addresses are <namespace>/<name>/<provider> with an optional leading hostname and an optional //subdir
+	registrySourceRegex = regexp.MustCompile(`^(?:(?P<host>(?:[0-9A-Za-z-]+\.)+[0-9A-Za-z-]+(?::[0-9]+)?|localhost(?::[0-9]+)?)/)?(?P<namespace>[0-9A-Za-z](?:[0-9A-Za-z_-]{0,62}[0-9A-Za-z])?)/(?P<name>[0-9A-Za-z](?:[0-9A-Za-z_-]{0,62}[0-9A-Za-z])?)/(?P<provider>[0-9a-z]{1,64})(?://(?P<submodule>.+))?$`)
+	// Hosts terraform treats as git shorthands rather than registries
+	registryDisallowedHosts = []string{"github.com", "bitbucket.org"}
+	// Full or abbreviated commit SHA
+	commitShaRegex = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+)
+
+func fixTrailingSlashForPath(path string) string {
+	if strings.HasSuffix(path, "/") {
+		return strings.TrimRight(path, "/")
+	}
+	return path
+}
+
+func getNamedMatchesForRegex(reg *regexp.Regexp, sourceString string) map[string]string {
+	match := reg.FindStringSubmatch(sourceString)
+	result := make(map[string]string)
+
+	if len(match) > 0 {
+		for i, name := range reg.SubexpNames() {
+			if i != 0 && name != "" {
+				result[name] = match[i]
+			}
+		}
+	}
+
+	return result
+}
+
+// Returns base url and cleaned paths
+func extractSubmoduleFromSource(source string) (string, string) {
+	subModuleMatch := getNamedMatchesForRegex(submoduleRegex, source)
+	var baseUrl = ""
+	var path = ""
+	// If there is a match and one group is matched(1st), then it
+	if len(subModuleMatch) > 0 && len(subModuleMatch["base_url"]) > 0 {
+		baseUrl = subModuleMatch["base_url"]
+	} else {
+		baseUrl = source
+	}
+	if len(subModuleMatch) > 0 && len(subModuleMatch["submodule"]) > 0 {
+		path = subModuleMatch["submodule"]
+	}
+	return baseUrl, path
+}
+
+func getTagFromUrl(source string) string {
+	var refTag string
+	refTagMatches := refRegex.FindStringSubmatch(source)
+	if len(refTagMatches) > 0 {
+		refTag = refTagMatches[2]
+		return refTag
+	}
+
+	return refTag
+}
+
+// Returns url, tag submodules(if any) in that order
+func extractRefAndPath(sourceUrl string) (string, string, string) {
+	var refTag, submodulePaths string
+	baseUrl, submodulePathsParams := extractSubmoduleFromSource(sourceUrl)
+	submodulePaths = removeUrlParams.ReplaceAllString(submodulePathsParams, "")
+	baseUrl = removeUrlParams.ReplaceAllString(baseUrl, "")
+	refTag = getTagFromUrl(sourceUrl)
+	log.Debug().Msgf("source :: extractRefAndPath ::  tag :: %s :: baseUrl :: %s :: submodulePaths :: %s", refTag, baseUrl, submodulePaths)
+
+	return baseUrl, refTag, submodulePaths
+}
+
+func extractModuleSource(match string) string {
+	var matchedString = ""
+	if len(match) > 0 {
+		matchedString = strings.ReplaceAll(match, "git::", "")
+		if strings.Contains(matchedString, "@") {
+			matchedString = strings.ReplaceAll(matchedString, "git::", "")
+			return matchedString
+		}
+		for _, regex := range maps.Keys(moduleSourceRegexMap) {
+			source := moduleSourceRegexMap[regex].FindStringSubmatch(matchedString)
+			if len(source) > 0 {
+				matchedString = strings.ReplaceAll(source[1], "git::", "")
+				break
+			}
+		}
+
+	}
+	return matchedString
+}
+
+// IsLocalSource reports whether the module source is a path on disk(./, ../ or absolute) rather than a remote source
+func IsLocalSource(source string) bool {
+	source = cleanUpSourceString(source)
+	if source == "." || source == ".." || strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") {
+		return true
+	}
+	return strings.HasPrefix(source, "/") || filepath.IsAbs(source)
+}
+
+// ParseGitSource returns url, tag and submodule(if any) of a git module source in that order. The url is empty for
+// sources that aren't git repositories.
+func ParseGitSource(line string) (string, string, string) {
+	if IsLocalSource(line) {
+		return "", "", ""
+	}
+	// Will help avoid running moduleSourceRegexMap on every string
+
+	repoLink := extractModuleSource(line)
+	//repoLink := sourceLineCheck[1]
+	log.Debug().Msg("source :: ParseGitSource :: Git repo link before:: " + repoLink)
+	var sourceUrl, refTag, submodule string
+	if repoLink != "" {
+
+		sourceUrl, refTag, submodule = extractRefAndPath(repoLink)
+	}
+	log.Debug().Msg("source :: ParseGitSource :: Git repo link after: " + sourceUrl)
+	return sourceUrl, refTag, submodule
+
+}
+
+func cleanUpSourceString(source string) string {
+	source = strings.ReplaceAll(source, "\"", "")
+	source = strings.ReplaceAll(source, " ", "")
+	return source
+}
+
+// Returns host, module address(namespace/name/provider) and submodule(if any) in that order. Host is empty for non registry sources.
+func extractRegistrySource(source string) (string, string, string) {
+	matches := getNamedMatchesForRegex(registrySourceRegex, source)
+	if len(matches) == 0 {
+		return "", "", ""
+	}
+	host := strings.ToLower(matches["host"])
+	if slices.Contains(registryDisallowedHosts, host) {
+		return "", "", ""
+	}
+	if host == "" {
+		host = PublicRegistryHost
+	}
+	moduleAddress := matches["namespace"] + "/" + matches["name"] + "/" + matches["provider"]
+	log.Debug().Msgf("source :: extractRegistrySource :: host :: %s :: module :: %s :: submodule :: %s", host, moduleAddress, matches["submodule"])
+	return host, moduleAddress, matches["submodule"]
+}
+
+func isRegistrySource(source string) bool {
+	host, _, _ := extractRegistrySource(source)
+	return host != ""
+}
+
+// SplitRegistryRepo splits the repo link recorded for registry modules back into host and module address
+func SplitRegistryRepo(repo string) (string, string) {
+	parts := strings.Split(repo, "/")
+	if len(parts) == 4 {
+		return parts[0], strings.Join(parts[1:], "/")
+	}
+	return PublicRegistryHost, repo
+}
+
+// RegistryRepoLink is the repo link recorded for registry modules. The public registry host is left out as terraform does.
+func RegistryRepoLink(host string, moduleAddress string) string {
+	if host == PublicRegistryHost {
+		return moduleAddress
+	}
+	return host + "/" + moduleAddress
+}
+
+// GetPinning classifies the ref of a git source or the version of a registry module as one of the Pinning constants.
+// Refs that are neither a version nor a commit are PinningUnknown, only the tags of the source tell a tag from a branch.
+func GetPinning(ref string) string {
+	if ref == "" {
+		return PinningNone
+	}
+	// Abbreviated SHAs of digits only are taken for versions, the others would parse as a version with a prerelease
+	if commitShaRegex.MatchString(ref) && (len(ref) == 40 || strings.ContainsAny(ref, "abcdef")) {
+		return PinningCommit
+	}
+	if _, err := version.NewVersion(ref); err == nil {
+		return PinningVersion
+	}
+	if _, err := version.NewConstraint(ref); err == nil {
+		return PinningVersion
+	}
+	return PinningUnknown
+}
+
+// ParseSource classifies the source(and version, for registry modules) of a module block as a registry module or a git
+// repository. The second value is false for local paths and when no repository could be extracted from the source.
+func ParseSource(source string, moduleVersion string) (ModuleUsage, bool) {
+	match := cleanUpSourceString(source)
+	log.Debug().Msgf("source :: ParseSource :: match :: %s", match)
+	if IsLocalSource(match) {
+		log.Debug().Msgf("source :: ParseSource :: skipping local source :: %s", match)
+		return ModuleUsage{}, false
+	}
+	if host, moduleAddress, submodule := extractRegistrySource(match); host != "" {
+		log.Debug().Msgf("source :: ParseSource :: registry :: %s :: version :: %s :: submodule :: %s", moduleAddress, moduleVersion, submodule)
+		return ModuleUsage{
+			Source:         match,
+			Repo:           RegistryRepoLink(host, moduleAddress),
+			SourceType:     RegistrySourceType,
+			CurrentVersion: moduleVersion,
+			Pinning:        GetPinning(moduleVersion),
+			Submodule:      submodule,
+		}, true
+	}
+	repo, tag, submodule := ParseGitSource(match)
+	log.Debug().Msgf("source :: ParseSource :: repo :: %s :: tag :: %s :: submodule :: %s", repo, tag, submodule)
+	if repo == "" {
+		return ModuleUsage{}, false
+	}
+	return ModuleUsage{Source: match, Repo: repo, SourceType: GitSourceType, CurrentVersion: tag, Pinning: GetPinning(tag), Submodule: submodule}, true
+}
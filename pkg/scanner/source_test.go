@@ -1,8 +1,7 @@
-package cmd
+package scanner
 
 import (
 	"github.com/sirupsen/logrus"
-	"os"
 	"regexp"
 	"testing"
 
@@ -148,27 +147,82 @@ func TestExtractSubmoduleFromSource(t *testing.T) {
 
 }
 
-func TestProcessRepoLinksAndTags(t *testing.T) {
-	fileContent := `
-	module "test"{
-	  source         = "git::https://github.com/Darth-Tech/terraform-modules//modules/fargate-cluster?ref=v1.0.2"
-  	  cluster_name   = "my-ecs"
-	}
-	`
-	err := os.Mkdir("./test", os.ModePerm)
-	Check(err, "readFiles :: TestProcessRepoLinksAndTags :: ")
-	fo, err := os.Create("./test/main.tf")
-	if err != nil {
-		panic(err)
+func TestExtractRegistrySource(t *testing.T) {
+	host, moduleAddress, submodule := extractRegistrySource("terraform-aws-modules/vpc/aws")
+	assert.Equal(t, PublicRegistryHost, host)
+	assert.Equal(t, "terraform-aws-modules/vpc/aws", moduleAddress)
+	assert.Empty(t, submodule)
+
+	host, moduleAddress, submodule = extractRegistrySource("app.terraform.io/example-corp/k8s-cluster/azurerm//modules/node-pool")
+	assert.Equal(t, "app.terraform.io", host)
+	assert.Equal(t, "example-corp/k8s-cluster/azurerm", moduleAddress)
+	assert.Equal(t, "modules/node-pool", submodule)
+
+	host, moduleAddress, _ = extractRegistrySource("localhost:8080/example-corp/vpc/aws")
+	assert.Equal(t, "localhost:8080", host)
+	assert.Equal(t, "example-corp/vpc/aws", moduleAddress)
+
+	assert.False(t, isRegistrySource("github.com/hashicorp/example"), "github shorthand detected as registry source")
+	assert.False(t, isRegistrySource("github.com/hashicorp/example/aws"), "github shorthand detected as registry source")
+	assert.False(t, isRegistrySource("bitbucket.org/hashicorp/terraform-consul-aws"), "bitbucket shorthand detected as registry source")
+	assert.False(t, isRegistrySource("git::https://github.com/test_repo_labala?ref=1.3.1"), "git source detected as registry source")
+	assert.False(t, isRegistrySource("https://example.com/vpc.git?ref=1.1.0"), "https source detected as registry source")
+	assert.False(t, isRegistrySource("./modules/vpc"), "local path detected as registry source")
+	assert.False(t, isRegistrySource("../vpc/aws/module"), "local path detected as registry source")
+}
+
+func TestRegistryRepoLink(t *testing.T) {
+	assert.Equal(t, "terraform-aws-modules/vpc/aws", RegistryRepoLink(PublicRegistryHost, "terraform-aws-modules/vpc/aws"))
+	host, moduleAddress := SplitRegistryRepo("terraform-aws-modules/vpc/aws")
+	assert.Equal(t, PublicRegistryHost, host)
+	assert.Equal(t, "terraform-aws-modules/vpc/aws", moduleAddress)
+
+	privateLink := RegistryRepoLink("app.terraform.io", "example-corp/vpc/aws")
+	assert.Equal(t, "app.terraform.io/example-corp/vpc/aws", privateLink)
+	host, moduleAddress = SplitRegistryRepo(privateLink)
+	assert.Equal(t, "app.terraform.io", host)
+	assert.Equal(t, "example-corp/vpc/aws", moduleAddress)
+}
+
+func TestParseSource(t *testing.T) {
+	module, ok := ParseSource(`"app.terraform.io/example-corp/k8s-cluster/azurerm//modules/node-pool"`, "~> 1.2")
+	assert.True(t, ok)
+	assert.Equal(t, ModuleUsage{Source: "app.terraform.io/example-corp/k8s-cluster/azurerm//modules/node-pool", Repo: "app.terraform.io/example-corp/k8s-cluster/azurerm", SourceType: RegistrySourceType, CurrentVersion: "~> 1.2", Pinning: PinningVersion, Submodule: "modules/node-pool"}, module)
+
+	module, ok = ParseSource("git::https://github.com/org/eks.git//modules/node?ref=v1.0.0", "")
+	assert.True(t, ok)
+	assert.Equal(t, ModuleUsage{Source: "git::https://github.com/org/eks.git//modules/node?ref=v1.0.0", Repo: "https://github.com/org/eks.git", SourceType: GitSourceType, CurrentVersion: "v1.0.0", Pinning: PinningVersion, Submodule: "modules/node"}, module)
+
+	module, ok = ParseSource("git::https://github.com/org/eks.git", "")
+	assert.True(t, ok, "source without a ref not parsed")
+	assert.Equal(t, PinningNone, module.Pinning)
+	assert.True(t, module.IsUnpinned())
+
+	module, ok = ParseSource("terraform-aws-modules/vpc/aws", "")
+	assert.True(t, ok)
+	assert.Equal(t, PinningNone, module.Pinning, "registry module without a version not unpinned")
+
+	_, ok = ParseSource("", "")
+	assert.False(t, ok, "empty source parsed as a module source")
+
+	for _, local := range []string{`"./modules/vpc"`, "../shared", "/opt/modules/vpc", "."} {
+		_, ok = ParseSource(local, "")
+		assert.False(t, ok, local+" parsed as a remote source")
+		repo, _, _ := ParseGitSource(local)
+		assert.Empty(t, repo, local+" parsed as a git source")
 	}
-	fo.WriteString(fileContent)
-	fo.Close()
-	data := processRepoLinksAndTags("./test/")
-	assert.Equal(t, 1, len(data))
-	assert.Equal(t, "https://github.com/Darth-Tech/terraform-modules", data[0]["repo"])
-	assert.Equal(t, "v1.0.2", data[0]["current_version"])
-	assert.Equal(t, "modules/fargate-cluster", data[0]["submodule"])
-
-	os.Remove("./test/main.tf")
-	os.Remove("./test/")
+}
+
+func TestGetPinning(t *testing.T) {
+	assert.Equal(t, PinningNone, GetPinning(""))
+	assert.Equal(t, PinningVersion, GetPinning("v1.0.2"))
+	assert.Equal(t, PinningVersion, GetPinning("1.0.2"))
+	assert.Equal(t, PinningVersion, GetPinning("~> 4.2"))
+	assert.Equal(t, PinningVersion, GetPinning(">= 3.0, < 5.0"))
+	assert.Equal(t, PinningVersion, GetPinning("2024010"), "digits only taken for a commit")
+	assert.Equal(t, PinningCommit, GetPinning("a1b2c3d"))
+	assert.Equal(t, PinningCommit, GetPinning("48fcbf3"), "sha starting with digits taken for a version")
+	assert.Equal(t, PinningCommit, GetPinning("4e1243bd22c66e76c2ba9eddc1f91394e57f9f83"))
+	assert.Equal(t, PinningUnknown, GetPinning("main"))
+	assert.Equal(t, PinningUnknown, GetPinning("release-2024"))
 }